@@ -1,67 +1,168 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"ride_sharing/backend/internal/auth"
 	"ride_sharing/backend/internal/config"
 	"ride_sharing/backend/internal/handlers"
+	"ride_sharing/backend/internal/logging"
+	"ride_sharing/backend/internal/metrics"
 	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/ratelimit"
 	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/tracing"
 
 	gorillaHandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("→ %s %s", r.Method, r.URL.Path)
-
-		start := time.Now()
-		next.ServeHTTP(w, r)
-
-		log.Printf("← Completed %s %s in %v", r.Method, r.URL.Path, time.Since(start))
-	})
-}
-
 func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting server initialization...")
+	// Keep amounts as JSON numbers, as clients received them before
+	// decimals replaced float64
+	decimal.MarshalJSONWithoutQuotes = true
 
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Route all logging through slog
+	logging.Setup(cfg.LogLevel, cfg.LogFormat)
+	slog.Info("starting server initialization", "profile", cfg.Env)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingEndpoint)
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize database
 	db, err := services.NewDatabase(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		slog.Error("failed to initialize database", "error", err)
+		os.Exit(1)
 	}
 
 	// Initialize user repository
 	userRepo := models.NewUserRepository(db.DB)
 	if err := userRepo.CreateTable(); err != nil {
-		log.Fatalf("Failed to migrate user table: %v", err)
+		slog.Error("failed to migrate user table", "error", err)
+		os.Exit(1)
 	}
 
+	// Background workers stop when SIGINT or SIGTERM cancels this context
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	// Initialize payments
+	paymentGateway, err := services.NewPaymentGateway(cfg)
+	if err != nil {
+		slog.Error("failed to initialize payment gateway", "error", err)
+		os.Exit(1)
+	}
+	ledgerService := services.NewLedgerService(db.DB, cfg.PlatformFeePercent)
+	paymentService := services.NewPaymentService(db.DB, paymentGateway, ledgerService)
+	cancellationPolicy := services.NewCancellationPolicy(cfg)
+
+	// Pay out driver balances on a schedule when enabled
+	if cfg.PayoutInterval > 0 {
+		payoutWorker := services.NewPayoutWorker(ledgerService, cfg.PayoutExportDir, decimal.NewFromFloat(cfg.PayoutMinimum), cfg.PayoutInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			payoutWorker.Run(ctx)
+		}()
+	}
+
+	// Initialize notifications and the worker that expires unanswered ride changes
+	notificationService := services.NewNotificationService(db.DB)
+	reconfirmationWorker := services.NewReconfirmationWorker(db.DB, notificationService, paymentService, cancellationPolicy, time.Minute)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		reconfirmationWorker.Run(ctx)
+	}()
+
 	// Initialize handlers
-	rideHandler := handlers.NewRideHandler(db.DB)
+	bookingHandler := handlers.NewBookingHandler(db.DB, notificationService, paymentService, cancellationPolicy)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	earningsHandler := handlers.NewEarningsHandler(ledgerService)
+	reviewHandler := handlers.NewReviewHandler(services.NewReviewService(db.DB, notificationService))
+
+	// Initialize file storage for uploaded photos
+	storage, err := services.NewStorage(cfg)
+	if err != nil {
+		slog.Error("failed to initialize storage", "error", err)
+		os.Exit(1)
+	}
+	photoService := services.NewPhotoService(db.DB, storage, int64(cfg.PhotoMaxBytes))
+	profileHandler := handlers.NewProfileHandler(db.DB, userRepo, photoService)
+	vehicleHandler := handlers.NewVehicleHandler(db.DB)
+	savedSearchHandler := handlers.NewSavedSearchHandler(db.DB)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	healthHandler := handlers.NewHealthHandler(db)
 
-	// Initialize Google Places service and handler
-	placesService := services.NewGooglePlacesService(cfg.GoogleMapsAPIKey)
-	placesHandler := handlers.NewGooglePlacesHandler(placesService)
+	// Initialize Places service and handler
+	placesProvider, err := services.NewPlacesProvider(cfg)
+	if err != nil {
+		slog.Error("failed to initialize places provider", "error", err)
+		os.Exit(1)
+	}
+	placesService := services.NewPlacesService(placesProvider, cfg)
+	placesHandler := handlers.NewPlacesHandler(placesService)
+	placesLimiter := ratelimit.New(cfg.PlacesRateLimit, cfg.PlacesRateBurst, cfg.TrustProxyHeaders)
+
+	// Initialize route estimates, geocoding through the Places service
+	routingProvider, err := services.NewRoutingProvider(cfg, placesService)
+	if err != nil {
+		slog.Error("failed to initialize routing provider", "error", err)
+		os.Exit(1)
+	}
+	pricingService := services.NewPricingService(cfg)
+	savedSearchService := services.NewSavedSearchService(db.DB, notificationService)
+	rideHandler := handlers.NewRideHandler(db.DB, notificationService, routingProvider, pricingService, paymentService, cancellationPolicy, savedSearchService)
+
+	// Recurring rides are created ahead of time by a background worker
+	scheduleService := services.NewScheduleService(db.DB, savedSearchService, cfg.ScheduleHorizonDays)
+	scheduleHandler := handlers.NewScheduleHandler(rideHandler, scheduleService)
+	scheduleWorker := services.NewScheduleWorker(scheduleService, cfg.ScheduleInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		scheduleWorker.Run(ctx)
+	}()
 
 	// Initialize auth service
 	authService := auth.NewAuthService(cfg, userRepo)
 
 	// Initialize router
 	router := mux.NewRouter()
-	router.Use(loggingMiddleware) // Add logging middleware to main router
+	router.Use(otelmux.Middleware(tracing.ServiceName)) // Continue incoming traces with a span per route
+	router.Use(logging.Middleware)                      // Assign request IDs and log every request
+	router.Use(metrics.Middleware)                      // Count requests and latency per route template
+
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/healthz", healthHandler.Healthz).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Readyz).Methods("GET")
 
 	// Register routes on the root router (no /api prefix)
-	router.HandleFunc("/rides", rideHandler.CreateRide).Methods("POST")
+	router.HandleFunc("/rides", authService.RequireAuthMux(rideHandler.CreateRide)).Methods("POST")
 	router.HandleFunc("/rides", rideHandler.GetRides).Methods("GET")
 
 	// // Footer links
@@ -71,15 +172,56 @@ func main() {
 
 	ridesRouter := router.PathPrefix("/rides").Subrouter()
 	ridesRouter.HandleFunc("/find", rideHandler.FindRides).Methods("GET")
+	ridesRouter.HandleFunc("/find/facets", rideHandler.FindRideFacets).Methods("GET")
+	ridesRouter.HandleFunc("/price-estimate", placesLimiter.Middleware("price_estimate", rideHandler.PriceEstimate)).Methods("GET")
 	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}", rideHandler.GetRide).Methods("GET")
-	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}", rideHandler.UpdateRide).Methods("PUT")
-	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}", rideHandler.DeleteRide).Methods("DELETE")
-	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/book", rideHandler.BookRide).Methods("POST")
-	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/request", rideHandler.CreateRideRequest).Methods("POST")
-	ridesRouter.HandleFunc("/requests", rideHandler.GetPendingRequests).Methods("GET")
-	ridesRouter.HandleFunc("/requests/{requestId:[0-9a-fA-F-]+}", rideHandler.HandleRideRequest).Methods("PUT")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(rideHandler.UpdateRide)).Methods("PUT", "PATCH")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(rideHandler.DeleteRide)).Methods("DELETE")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/complete", authService.RequireAuthMux(rideHandler.CompleteRide)).Methods("POST")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/join", authService.RequireAuthMux(rideHandler.JoinRide)).Methods("POST")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/book", authService.RequireAuthMux(rideHandler.BookRide)).Methods("POST")
+	ridesRouter.HandleFunc("/{id:[0-9a-fA-F-]+}/request", authService.RequireAuthMux(rideHandler.CreateRideRequest)).Methods("POST")
+	ridesRouter.HandleFunc("/requests", authService.RequireAuthMux(rideHandler.GetPendingRequests)).Methods("GET")
+	ridesRouter.HandleFunc("/requests/{requestId:[0-9a-fA-F-]+}", authService.RequireAuthMux(rideHandler.HandleRideRequest)).Methods("PUT")
+
+	router.HandleFunc("/schedules", authService.RequireAuthMux(scheduleHandler.CreateSchedule)).Methods("POST")
+	router.HandleFunc("/schedules/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(scheduleHandler.UpdateSchedule)).Methods("PATCH")
+	router.HandleFunc("/schedules/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(scheduleHandler.CancelSchedule)).Methods("DELETE")
+
+	router.HandleFunc("/bookings/{id:[0-9a-fA-F-]+}/reconfirm", authService.RequireAuthMux(bookingHandler.ReconfirmBooking)).Methods("POST")
+	router.HandleFunc("/bookings/{id:[0-9a-fA-F-]+}/cancel", authService.RequireAuthMux(bookingHandler.CancelBooking)).Methods("POST")
+	router.HandleFunc("/bookings/{id:[0-9a-fA-F-]+}/no-show", authService.RequireAuthMux(bookingHandler.MarkNoShow)).Methods("POST")
+	router.HandleFunc("/bookings/{id:[0-9a-fA-F-]+}/review", authService.RequireAuthMux(reviewHandler.ReviewBooking)).Methods("POST")
+
+	router.HandleFunc("/users/{id:[0-9a-fA-F-]+}", profileHandler.GetUser).Methods("GET")
+	router.HandleFunc("/users/{id:[0-9a-fA-F-]+}/reviews", reviewHandler.GetUserReviews).Methods("GET")
 
-	router.HandleFunc("/places-autocomplete", placesHandler.Autocomplete).Methods("GET")
+	router.HandleFunc("/me", authService.RequireAuthMux(profileHandler.GetMe)).Methods("GET")
+	router.HandleFunc("/me", authService.RequireAuthMux(profileHandler.UpdateMe)).Methods("PATCH")
+	router.HandleFunc("/me/photo", authService.RequireAuthMux(profileHandler.UploadPhoto)).Methods("POST")
+	router.HandleFunc("/me/searches", authService.RequireAuthMux(savedSearchHandler.GetSavedSearches)).Methods("GET")
+	router.HandleFunc("/me/searches", authService.RequireAuthMux(savedSearchHandler.CreateSavedSearch)).Methods("POST")
+	router.HandleFunc("/me/searches/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(savedSearchHandler.DeleteSavedSearch)).Methods("DELETE")
+	router.HandleFunc("/me/schedules", authService.RequireAuthMux(scheduleHandler.GetSchedules)).Methods("GET")
+	router.HandleFunc("/me/vehicles", authService.RequireAuthMux(vehicleHandler.GetVehicles)).Methods("GET")
+	router.HandleFunc("/me/vehicles", authService.RequireAuthMux(vehicleHandler.CreateVehicle)).Methods("POST")
+	router.HandleFunc("/me/vehicles/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(vehicleHandler.UpdateVehicle)).Methods("PUT")
+	router.HandleFunc("/me/vehicles/{id:[0-9a-fA-F-]+}", authService.RequireAuthMux(vehicleHandler.DeleteVehicle)).Methods("DELETE")
+
+	// Files kept on local disk are served by the API itself
+	if local, ok := storage.(*services.LocalStorage); ok {
+		router.PathPrefix("/media/").Handler(http.StripPrefix("/media", local.Handler())).Methods("GET", "HEAD")
+	}
+
+	// Signed by the gateway rather than a user token
+	router.HandleFunc("/payments/webhook", paymentHandler.Webhook).Methods("POST")
+
+	router.HandleFunc("/me/notifications", authService.RequireAuthMux(notificationHandler.GetNotifications)).Methods("GET")
+	router.HandleFunc("/me/notifications/{id:[0-9a-fA-F-]+}/read", authService.RequireAuthMux(notificationHandler.MarkRead)).Methods("POST")
+	router.HandleFunc("/me/earnings", authService.RequireAuthMux(earningsHandler.GetEarnings)).Methods("GET")
+
+	router.HandleFunc("/places-autocomplete", placesLimiter.Middleware("places", placesHandler.Autocomplete)).Methods("GET")
+	router.HandleFunc("/places/{placeId:[A-Za-z0-9_-]+}", placesLimiter.Middleware("places", placesHandler.GetPlace)).Methods("GET")
 
 	router.HandleFunc("/auth/google/login", authService.GoogleLoginMux).Methods("GET")
 	router.HandleFunc("/auth/google/callback", authService.GoogleCallbackMux).Methods("GET")
@@ -90,23 +232,54 @@ func main() {
 
 	// Add CORS middleware
 	corsMiddleware := gorillaHandlers.CORS(
-		gorillaHandlers.AllowedOrigins([]string{"http://localhost:4200"}), // Standard Angular port
-		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		gorillaHandlers.AllowedOrigins(cfg.CORSAllowedOrigins),
+		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", logging.RequestIDHeader}),
+		gorillaHandlers.ExposedHeaders([]string{logging.RequestIDHeader}),
 	)
 
 	// Create final handler chain
 	handler := corsMiddleware(router)
 
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	port := cfg.Port
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", port, "endpoint", cfg.APIBaseURL)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("server stopped", "error", err)
+		stop()
+	case <-ctx.Done():
+		slog.Info("shutdown signal received, draining requests")
+	}
+
+	// Fail readiness first so no new traffic arrives, then let in-flight
+	// requests and their transactions finish
+	healthHandler.SetShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("graceful shutdown failed", "error", err)
 	}
 
-	log.Printf("Server starting on port %s", port)
-	log.Printf("API endpoint: http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
-		log.Fatal(err)
+	workers.Wait()
+	if err := db.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
 	}
+	slog.Info("server stopped")
 }
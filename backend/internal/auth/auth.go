@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/dto"
 	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -30,6 +33,7 @@ type AuthService struct {
 	googleConfig   *oauth2.Config
 	facebookConfig *oauth2.Config
 	jwtSecret      []byte
+	frontendURL    string
 	userRepo       *models.UserRepository
 }
 
@@ -38,7 +42,7 @@ func NewAuthService(config *config.Config, userRepo *models.UserRepository) *Aut
 		googleConfig: &oauth2.Config{
 			ClientID:     config.GoogleClientID,
 			ClientSecret: config.GoogleClientSecret,
-			RedirectURL:  "http://localhost:8080/auth/google/callback",
+			RedirectURL:  config.GoogleRedirectURL,
 			Scopes: []string{
 				"https://www.googleapis.com/auth/userinfo.email",
 				"https://www.googleapis.com/auth/userinfo.profile",
@@ -48,12 +52,13 @@ func NewAuthService(config *config.Config, userRepo *models.UserRepository) *Aut
 		facebookConfig: &oauth2.Config{
 			ClientID:     config.FacebookClientID,
 			ClientSecret: config.FacebookClientSecret,
-			RedirectURL:  "http://localhost:8080/auth/facebook/callback",
+			RedirectURL:  config.FacebookRedirectURL,
 			Scopes:       []string{"email", "public_profile"},
 			Endpoint:     facebook.Endpoint,
 		},
-		jwtSecret: []byte(config.JWTSecret),
-		userRepo:  userRepo,
+		jwtSecret:   []byte(config.JWTSecret),
+		frontendURL: strings.TrimRight(config.FrontendURL, "/"),
+		userRepo:    userRepo,
 	}
 }
 
@@ -186,7 +191,7 @@ func (s *AuthService) GoogleCallback(c *gin.Context) {
 	}
 
 	// Redirect to frontend with token
-	frontendURL := "http://localhost:4200/login?token=" + jwtToken
+	frontendURL := s.frontendURL + "/login?token=" + jwtToken
 	c.Redirect(http.StatusTemporaryRedirect, frontendURL)
 }
 
@@ -414,7 +419,7 @@ func (s *AuthService) GoogleCallbackMux(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	frontendURL := "http://localhost:4200/login?token=" + jwtToken
+	frontendURL := s.frontendURL + "/login?token=" + jwtToken
 	http.Redirect(w, r, frontendURL, http.StatusTemporaryRedirect)
 }
 
@@ -468,21 +473,22 @@ func (s *AuthService) FacebookCallbackMux(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	frontendURL := "http://localhost:4200/login?token=" + jwtToken
+	frontendURL := s.frontendURL + "/login?token=" + jwtToken
 	http.Redirect(w, r, frontendURL, http.StatusTemporaryRedirect)
 }
 
 // Email Signup (Gorilla Mux)
 func (s *AuthService) EmailSignupMux(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-		Name     string `json:"name"`
-	}
+	var input dto.SignupInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	input.Normalize()
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
 
 	existingUser, err := s.userRepo.GetByEmail(input.Email)
 	if err != nil {
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -39,3 +40,38 @@ func (s *AuthService) AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+type contextKey string
+
+const userContextKey contextKey = "user"
+
+// UserFromContext returns the authenticated user stored by RequireAuthMux.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	user, ok := ctx.Value(userContextKey).(*User)
+	return user, ok
+}
+
+// RequireAuthMux rejects requests without a valid bearer token and stores
+// the token's user in the request context (Gorilla Mux)
+func (s *AuthService) RequireAuthMux(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+			return
+		}
+
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := s.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	}
+}
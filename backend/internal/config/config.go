@@ -1,52 +1,529 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Profiles select the defaults applied before the config file and
+// environment overrides.
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// Config is loaded from profile defaults, then an optional YAML file named
+// by CONFIG_FILE, then environment variables named by each field's env tag.
 type Config struct {
-	DBHost           string
-	DBUser           string
-	DBPassword       string
-	DBName           string
-	DBPort           string
-	GoogleMapsAPIKey string
+	Env string `yaml:"env" env:"APP_ENV"`
+
+	// HTTP server
+	Port               string        `yaml:"port" env:"PORT"`
+	APIBaseURL         string        `yaml:"api_base_url" env:"API_BASE_URL"`
+	FrontendURL        string        `yaml:"frontend_url" env:"FRONTEND_URL"`
+	CORSAllowedOrigins []string      `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	ReadTimeout        time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout       time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+
+	// Database
+	DBHost     string `yaml:"db_host" env:"DB_HOST"`
+	DBUser     string `yaml:"db_user" env:"DB_USER"`
+	DBPassword string `yaml:"db_password" env:"DB_PASSWORD"`
+	DBName     string `yaml:"db_name" env:"DB_NAME"`
+	DBPort     string `yaml:"db_port" env:"DB_PORT"`
+
+	// Places lookups. PlacesProvider is "google" or "gazetteer", the latter
+	// answering from PlacesGazetteerFile without network access.
+	PlacesProvider      string        `yaml:"places_provider" env:"PLACES_PROVIDER"`
+	PlacesCountry       string        `yaml:"places_country" env:"PLACES_COUNTRY"`
+	PlacesGazetteerFile string        `yaml:"places_gazetteer_file" env:"PLACES_GAZETTEER_FILE"`
+	GoogleMapsAPIKey    string        `yaml:"google_maps_api_key" env:"GOOGLE_MAPS_API_KEY"`
+	PlacesTimeout       time.Duration `yaml:"places_timeout" env:"PLACES_TIMEOUT"`
+	PlacesCacheSize     int           `yaml:"places_cache_size" env:"PLACES_CACHE_SIZE"`
+	PlacesCacheTTL      time.Duration `yaml:"places_cache_ttl" env:"PLACES_CACHE_TTL"`
+	PlacesRateLimit     float64       `yaml:"places_rate_limit" env:"PLACES_RATE_LIMIT"`
+	PlacesRateBurst     int           `yaml:"places_rate_burst" env:"PLACES_RATE_BURST"`
+	TrustProxyHeaders   bool          `yaml:"trust_proxy_headers" env:"TRUST_PROXY_HEADERS"`
+
+	// Route estimates. RoutingProvider is "google", which falls back to a
+	// straight-line estimate, or "haversine" to run offline.
+	RoutingProvider        string  `yaml:"routing_provider" env:"ROUTING_PROVIDER"`
+	RoutingAverageSpeedKmh float64 `yaml:"routing_average_speed_kmh" env:"ROUTING_AVERAGE_SPEED_KMH"`
+
+	// Money. Amounts are in Currency (ISO 4217); passengers pay a booking
+	// fee of BookingFeeFixed plus BookingFeePercent of the seats' price.
+	Currency          string  `yaml:"currency" env:"CURRENCY"`
+	BookingFeePercent float64 `yaml:"booking_fee_percent" env:"BOOKING_FEE_PERCENT"`
+	BookingFeeFixed   float64 `yaml:"booking_fee_fixed" env:"BOOKING_FEE_FIXED"`
+
+	// Payments. PaymentProvider is "fake", an in-memory gateway that
+	// declines authorizations above FakePaymentDeclineAbove when positive.
+	// It forgets payments on restart, so it is only for dev and test.
+	PaymentProvider         string  `yaml:"payment_provider" env:"PAYMENT_PROVIDER"`
+	PaymentWebhookSecret    string  `yaml:"payment_webhook_secret" env:"PAYMENT_WEBHOOK_SECRET"`
+	FakePaymentDeclineAbove float64 `yaml:"fake_payment_decline_above" env:"FAKE_PAYMENT_DECLINE_ABOVE"`
+
+	// Driver earnings. Drivers are credited booking totals less
+	// PlatformFeePercent; balances of at least PayoutMinimum are paid out
+	// every PayoutInterval (0 disables) and exported to PayoutExportDir.
+	PlatformFeePercent float64       `yaml:"platform_fee_percent" env:"PLATFORM_FEE_PERCENT"`
+	PayoutInterval     time.Duration `yaml:"payout_interval" env:"PAYOUT_INTERVAL"`
+	PayoutMinimum      float64       `yaml:"payout_minimum" env:"PAYOUT_MINIMUM"`
+	PayoutExportDir    string        `yaml:"payout_export_dir" env:"PAYOUT_EXPORT_DIR"`
+
+	// Recurring rides. Every ScheduleInterval, rides departing within the
+	// next ScheduleHorizonDays are created from active schedules.
+	ScheduleHorizonDays int           `yaml:"schedule_horizon_days" env:"SCHEDULE_HORIZON_DAYS"`
+	ScheduleInterval    time.Duration `yaml:"schedule_interval" env:"SCHEDULE_INTERVAL"`
+
+	// Cancellation refunds, see services.CancellationPolicy
+	CancellationFullRefundBefore     time.Duration `yaml:"cancellation_full_refund_before" env:"CANCELLATION_FULL_REFUND_BEFORE"`
+	CancellationPartialRefundPercent float64       `yaml:"cancellation_partial_refund_percent" env:"CANCELLATION_PARTIAL_REFUND_PERCENT"`
+	CancellationGracePeriod          time.Duration `yaml:"cancellation_grace_period" env:"CANCELLATION_GRACE_PERIOD"`
+
+	// Suggested fares. The cost of driving a route is split into
+	// PricingCostShares parts; listings may exceed the suggestion by at most
+	// PricingMaxMarkup times, and PricingMaxFare (0 for none) caps any fare.
+	PricingCostPerKm          float64 `yaml:"pricing_cost_per_km" env:"PRICING_COST_PER_KM"`
+	PricingCostPerMinute      float64 `yaml:"pricing_cost_per_minute" env:"PRICING_COST_PER_MINUTE"`
+	PricingFuelPricePerLitre  float64 `yaml:"pricing_fuel_price_per_litre" env:"PRICING_FUEL_PRICE_PER_LITRE"`
+	PricingFuelLitresPer100Km float64 `yaml:"pricing_fuel_litres_per_100km" env:"PRICING_FUEL_LITRES_PER_100KM"`
+	PricingCostShares         int     `yaml:"pricing_cost_shares" env:"PRICING_COST_SHARES"`
+	PricingMaxMarkup          float64 `yaml:"pricing_max_markup" env:"PRICING_MAX_MARKUP"`
+	PricingMinFare            float64 `yaml:"pricing_min_fare" env:"PRICING_MIN_FARE"`
+	PricingMaxFare            float64 `yaml:"pricing_max_fare" env:"PRICING_MAX_FARE"`
+
+	// Uploaded files. StorageProvider is "local", keeping files under
+	// StorageLocalDir and serving them from /media, or "s3" for any
+	// S3-compatible bucket (MinIO works as a local stand-in).
+	// StoragePublicURL is where stored files are read from.
+	StorageProvider   string `yaml:"storage_provider" env:"STORAGE_PROVIDER"`
+	StorageLocalDir   string `yaml:"storage_local_dir" env:"STORAGE_LOCAL_DIR"`
+	StoragePublicURL  string `yaml:"storage_public_url" env:"STORAGE_PUBLIC_URL"`
+	S3Endpoint        string `yaml:"s3_endpoint" env:"S3_ENDPOINT"`
+	S3Region          string `yaml:"s3_region" env:"S3_REGION"`
+	S3Bucket          string `yaml:"s3_bucket" env:"S3_BUCKET"`
+	S3AccessKeyID     string `yaml:"s3_access_key_id" env:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string `yaml:"s3_secret_access_key" env:"S3_SECRET_ACCESS_KEY"`
+	PhotoMaxBytes     int    `yaml:"photo_max_bytes" env:"PHOTO_MAX_BYTES"`
+
 	// OAuth Configuration
-	GoogleClientID       string
-	GoogleClientSecret   string
-	FacebookClientID     string
-	FacebookClientSecret string
-	JWTSecret            string
+	GoogleClientID       string `yaml:"google_client_id" env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret   string `yaml:"google_client_secret" env:"GOOGLE_CLIENT_SECRET"`
+	GoogleRedirectURL    string `yaml:"google_redirect_url" env:"GOOGLE_REDIRECT_URL"`
+	FacebookClientID     string `yaml:"facebook_client_id" env:"FACEBOOK_CLIENT_ID"`
+	FacebookClientSecret string `yaml:"facebook_client_secret" env:"FACEBOOK_CLIENT_SECRET"`
+	FacebookRedirectURL  string `yaml:"facebook_redirect_url" env:"FACEBOOK_REDIRECT_URL"`
+	JWTSecret            string `yaml:"jwt_secret" env:"JWT_SECRET"`
+
+	// Logging
+	LogLevel  string `yaml:"log_level" env:"LOG_LEVEL"`
+	LogFormat string `yaml:"log_format" env:"LOG_FORMAT"`
+
+	// Tracing
+	TracingExporter string `yaml:"tracing_exporter" env:"TRACING_EXPORTER"`
+	TracingEndpoint string `yaml:"tracing_endpoint" env:"TRACING_ENDPOINT"`
+}
+
+// LoadConfig builds the configuration and validates it. Callers should
+// treat an error as fatal.
+func LoadConfig() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("could not load .env file", "error", err)
+	}
+
+	// The profile comes from APP_ENV or, failing that, the config file, and
+	// must be known before defaults are applied
+	profile := os.Getenv("APP_ENV")
+	var file []byte
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+		file = data
+		if profile == "" {
+			var peek struct {
+				Env string `yaml:"env"`
+			}
+			if err := yaml.Unmarshal(file, &peek); err != nil {
+				return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+			}
+			profile = peek.Env
+		}
+	}
+
+	cfg := defaults(normalizeProfile(profile))
+	if file != nil {
+		if err := yaml.Unmarshal(file, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", os.Getenv("CONFIG_FILE"), err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.Env = normalizeProfile(cfg.Env)
+	cfg.derive()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// defaults returns the settings for a profile. Development and test run
+// against a local frontend and database; production has no URL defaults so
+// every public address must be configured explicitly.
+func defaults(profile string) *Config {
+	cfg := &Config{
+		Env:             profile,
+		Port:            "8080",
+		DBPort:          "5432",
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+		LogLevel:        "info",
+		LogFormat:       "text",
+		TracingExporter: "none",
+		PlacesProvider:  "google",
+		PlacesCountry:   "us",
+		PlacesTimeout:   5 * time.Second,
+		PlacesCacheSize: 5000,
+		PlacesCacheTTL:  24 * time.Hour,
+		PlacesRateLimit: 5,
+		PlacesRateBurst: 20,
+
+		RoutingProvider:        "google",
+		RoutingAverageSpeedKmh: 70,
+
+		Currency: "USD",
+
+		PlatformFeePercent: 10,
+		PayoutInterval:     7 * 24 * time.Hour,
+		PayoutMinimum:      10,
+		PayoutExportDir:    "payouts",
+
+		ScheduleHorizonDays: 14,
+		ScheduleInterval:    time.Hour,
+
+		CancellationFullRefundBefore:     24 * time.Hour,
+		CancellationPartialRefundPercent: 50,
+		CancellationGracePeriod:          time.Hour,
+
+		PricingCostPerKm:          0.08,
+		PricingFuelPricePerLitre:  1.60,
+		PricingFuelLitresPer100Km: 7,
+		PricingCostShares:         4,
+		PricingMaxMarkup:          1.5,
+		PricingMinFare:            2,
+
+		StorageProvider: "local",
+		StorageLocalDir: "uploads",
+		S3Region:        "us-east-1",
+		PhotoMaxBytes:   5 << 20,
+	}
+
+	switch profile {
+	case ProfileDev:
+		cfg.APIBaseURL = "http://localhost:8080"
+		cfg.FrontendURL = "http://localhost:4200"
+		cfg.DBHost = "localhost"
+		cfg.LogLevel = "debug"
+		cfg.PaymentProvider = "fake"
+		cfg.PaymentWebhookSecret = "dev-webhook-secret"
+	case ProfileTest:
+		cfg.APIBaseURL = "http://localhost:8080"
+		cfg.FrontendURL = "http://localhost:4200"
+		cfg.DBHost = "localhost"
+		cfg.DBName = "ride_sharing_test"
+		cfg.LogLevel = "warn"
+		cfg.PlacesProvider = "gazetteer"
+		cfg.PlacesGazetteerFile = "data/gazetteer.json"
+		cfg.RoutingProvider = "haversine"
+		cfg.PaymentProvider = "fake"
+		cfg.PaymentWebhookSecret = "test-webhook-secret"
+	case ProfileProd:
+		cfg.LogFormat = "json"
+	}
+
+	return cfg
+}
+
+func normalizeProfile(profile string) string {
+	switch strings.ToLower(strings.TrimSpace(profile)) {
+	case "", "dev", "development", "local":
+		return ProfileDev
+	case "test", "testing":
+		return ProfileTest
+	case "prod", "production":
+		return ProfileProd
+	default:
+		return strings.ToLower(strings.TrimSpace(profile))
+	}
 }
 
-func LoadConfig() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("Error loading .env file: %v", err)
+// applyEnv overrides fields from the environment variables named in their
+// env tags. Unset or empty variables leave the field unchanged.
+func (c *Config) applyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("env")
+		value := os.Getenv(key)
+		if key == "" || value == "" {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
 	}
+	return nil
+}
 
-	return &Config{
-		DBHost:               os.Getenv("DB_HOST"),
-		DBUser:               os.Getenv("DB_USER"),
-		DBPassword:           os.Getenv("DB_PASSWORD"),
-		DBName:               os.Getenv("DB_NAME"),
-		DBPort:               os.Getenv("DB_PORT"),
-		GoogleMapsAPIKey:     os.Getenv("GOOGLE_MAPS_API_KEY"),
-		GoogleClientID:       os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret:   os.Getenv("GOOGLE_CLIENT_SECRET"),
-		FacebookClientID:     os.Getenv("FACEBOOK_CLIENT_ID"),
-		FacebookClientSecret: os.Getenv("FACEBOOK_CLIENT_SECRET"),
-		JWTSecret:            os.Getenv("JWT_SECRET"),
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
 	}
+	return nil
 }
 
-func getEnvWithDefault(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// derive fills settings that default to values built from other settings.
+func (c *Config) derive() {
+	base := strings.TrimRight(c.APIBaseURL, "/")
+	if c.GoogleRedirectURL == "" && base != "" {
+		c.GoogleRedirectURL = base + "/auth/google/callback"
+	}
+	if c.FacebookRedirectURL == "" && base != "" {
+		c.FacebookRedirectURL = base + "/auth/facebook/callback"
+	}
+	if len(c.CORSAllowedOrigins) == 0 && c.FrontendURL != "" {
+		c.CORSAllowedOrigins = []string{strings.TrimRight(c.FrontendURL, "/")}
+	}
+	c.PlacesProvider = strings.ToLower(strings.TrimSpace(c.PlacesProvider))
+	c.PlacesCountry = strings.ToLower(strings.TrimSpace(c.PlacesCountry))
+	c.RoutingProvider = strings.ToLower(strings.TrimSpace(c.RoutingProvider))
+	c.Currency = strings.ToUpper(strings.TrimSpace(c.Currency))
+	c.PaymentProvider = strings.ToLower(strings.TrimSpace(c.PaymentProvider))
+	c.StorageProvider = strings.ToLower(strings.TrimSpace(c.StorageProvider))
+	if c.StoragePublicURL == "" {
+		switch {
+		case c.StorageProvider == "local" && base != "":
+			c.StoragePublicURL = base + "/media"
+		case c.StorageProvider == "s3" && c.S3Endpoint != "":
+			c.StoragePublicURL = strings.TrimRight(c.S3Endpoint, "/") + "/" + c.S3Bucket
+		}
+	}
+}
+
+// Validate reports every missing or malformed setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	require := func(value, name string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+	requireURL := func(value, name string) {
+		require(value, name)
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, name+" must be an absolute URL")
+		}
+	}
+	oneOf := func(value, name string, allowed ...string) {
+		for _, a := range allowed {
+			if strings.EqualFold(value, a) {
+				return
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s must be one of %s", name, strings.Join(allowed, ", ")))
+	}
+
+	oneOf(c.Env, "APP_ENV", ProfileDev, ProfileTest, ProfileProd)
+	require(c.Port, "PORT")
+	require(c.DBHost, "DB_HOST")
+	require(c.DBUser, "DB_USER")
+	require(c.DBName, "DB_NAME")
+	require(c.DBPort, "DB_PORT")
+	require(c.JWTSecret, "JWT_SECRET")
+	requireURL(c.APIBaseURL, "API_BASE_URL")
+	requireURL(c.FrontendURL, "FRONTEND_URL")
+	requireURL(c.GoogleRedirectURL, "GOOGLE_REDIRECT_URL")
+	requireURL(c.FacebookRedirectURL, "FACEBOOK_REDIRECT_URL")
+	if len(c.CORSAllowedOrigins) == 0 {
+		problems = append(problems, "CORS_ALLOWED_ORIGINS is required")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		requireURL(origin, "CORS_ALLOWED_ORIGINS entry "+origin)
+	}
+	oneOf(c.LogLevel, "LOG_LEVEL", "debug", "info", "warn", "error")
+	oneOf(c.LogFormat, "LOG_FORMAT", "text", "json")
+	oneOf(c.TracingExporter, "TRACING_EXPORTER", "none", "stdout", "otlp")
+
+	for name, d := range map[string]time.Duration{
+		"SERVER_READ_TIMEOUT":     c.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":    c.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":     c.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT": c.ShutdownTimeout,
+		"PLACES_TIMEOUT":          c.PlacesTimeout,
+		"PLACES_CACHE_TTL":        c.PlacesCacheTTL,
+	} {
+		if d <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+
+	oneOf(c.PlacesProvider, "PLACES_PROVIDER", "google", "gazetteer")
+	if c.PlacesProvider == "gazetteer" {
+		require(c.PlacesGazetteerFile, "PLACES_GAZETTEER_FILE")
+	}
+	if c.PlacesCountry != "" && len(c.PlacesCountry) != 2 {
+		problems = append(problems, "PLACES_COUNTRY must be a two-letter country code")
+	}
+	oneOf(c.RoutingProvider, "ROUTING_PROVIDER", "google", "haversine")
+	if c.RoutingAverageSpeedKmh <= 0 {
+		problems = append(problems, "ROUTING_AVERAGE_SPEED_KMH must be positive")
+	}
+	for name, v := range map[string]float64{
+		"PRICING_COST_PER_KM":           c.PricingCostPerKm,
+		"PRICING_COST_PER_MINUTE":       c.PricingCostPerMinute,
+		"PRICING_FUEL_PRICE_PER_LITRE":  c.PricingFuelPricePerLitre,
+		"PRICING_FUEL_LITRES_PER_100KM": c.PricingFuelLitresPer100Km,
+		"PRICING_MAX_MARKUP":            c.PricingMaxMarkup,
+		"PRICING_MIN_FARE":              c.PricingMinFare,
+		"PRICING_MAX_FARE":              c.PricingMaxFare,
+		"BOOKING_FEE_PERCENT":           c.BookingFeePercent,
+		"BOOKING_FEE_FIXED":             c.BookingFeeFixed,
+		"FAKE_PAYMENT_DECLINE_ABOVE":    c.FakePaymentDeclineAbove,
+	} {
+		if v < 0 {
+			problems = append(problems, name+" cannot be negative")
+		}
+	}
+	oneOf(c.StorageProvider, "STORAGE_PROVIDER", "local", "s3")
+	switch c.StorageProvider {
+	case "local":
+		require(c.StorageLocalDir, "STORAGE_LOCAL_DIR")
+	case "s3":
+		requireURL(c.S3Endpoint, "S3_ENDPOINT")
+		require(c.S3Region, "S3_REGION")
+		require(c.S3Bucket, "S3_BUCKET")
+		require(c.S3AccessKeyID, "S3_ACCESS_KEY_ID")
+		require(c.S3SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	}
+	requireURL(c.StoragePublicURL, "STORAGE_PUBLIC_URL")
+	if c.PhotoMaxBytes <= 0 {
+		problems = append(problems, "PHOTO_MAX_BYTES must be positive")
+	}
+	oneOf(c.PaymentProvider, "PAYMENT_PROVIDER", "fake")
+	require(c.PaymentWebhookSecret, "PAYMENT_WEBHOOK_SECRET")
+	if c.CancellationFullRefundBefore < 0 || c.CancellationGracePeriod < 0 {
+		problems = append(problems, "CANCELLATION_FULL_REFUND_BEFORE and CANCELLATION_GRACE_PERIOD cannot be negative")
+	}
+	if c.PlatformFeePercent < 0 || c.PlatformFeePercent > 100 {
+		problems = append(problems, "PLATFORM_FEE_PERCENT must be between 0 and 100")
+	}
+	if c.PayoutInterval < 0 || c.PayoutMinimum < 0 {
+		problems = append(problems, "PAYOUT_INTERVAL and PAYOUT_MINIMUM cannot be negative")
+	}
+	if c.PayoutInterval > 0 {
+		require(c.PayoutExportDir, "PAYOUT_EXPORT_DIR")
+	}
+	if c.ScheduleHorizonDays < 1 || c.ScheduleHorizonDays > 90 {
+		problems = append(problems, "SCHEDULE_HORIZON_DAYS must be between 1 and 90")
+	}
+	if c.ScheduleInterval <= 0 {
+		problems = append(problems, "SCHEDULE_INTERVAL must be positive")
+	}
+	if c.CancellationPartialRefundPercent < 0 || c.CancellationPartialRefundPercent > 100 {
+		problems = append(problems, "CANCELLATION_PARTIAL_REFUND_PERCENT must be between 0 and 100")
+	}
+	if len(c.Currency) != 3 {
+		problems = append(problems, "CURRENCY must be a three-letter ISO 4217 code")
+	}
+	if c.PricingCostShares < 1 {
+		problems = append(problems, "PRICING_COST_SHARES must be at least 1")
+	}
+	if c.PricingMaxFare > 0 && c.PricingMaxFare < c.PricingMinFare {
+		problems = append(problems, "PRICING_MAX_FARE cannot be below PRICING_MIN_FARE")
+	}
+	if c.PlacesCacheSize < 0 {
+		problems = append(problems, "PLACES_CACHE_SIZE cannot be negative")
+	}
+	if c.PlacesRateLimit <= 0 || c.PlacesRateBurst < 1 {
+		problems = append(problems, "PLACES_RATE_LIMIT and PLACES_RATE_BURST must be positive")
+	}
+
+	if c.Env == ProfileProd && (c.PlacesProvider == "google" || c.RoutingProvider == "google") {
+		require(c.GoogleMapsAPIKey, "GOOGLE_MAPS_API_KEY")
+	}
+	if c.Env == ProfileProd {
+		require(c.DBPassword, "DB_PASSWORD")
+		if c.PaymentProvider == "fake" {
+			problems = append(problems, "PAYMENT_PROVIDER fake keeps payments in memory and cannot be used in production")
+		}
+		if len(c.JWTSecret) < 32 {
+			problems = append(problems, "JWT_SECRET must be at least 32 characters in production")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
 	}
-	return value
+	sort.Strings(problems)
+	return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
 }
@@ -3,14 +3,17 @@ package config
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	_ "github.com/lib/pq"
 )
 
 // NewDB creates a new database connection
 func NewDB() (*sql.DB, error) {
-	config := LoadConfig()
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	// Validate required configuration
 	if config.DBPassword == "" {
@@ -34,6 +37,6 @@ func NewDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("error connecting to the database: %v", err)
 	}
 
-	log.Println("Successfully connected to database")
+	slog.Info("connected to database")
 	return db, nil
 }
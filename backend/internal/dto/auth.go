@@ -0,0 +1,16 @@
+package dto
+
+import "strings"
+
+// SignupInput is the body accepted by POST /auth/email/signup.
+type SignupInput struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+// Normalize trims surrounding whitespace from the email and name.
+func (in *SignupInput) Normalize() {
+	in.Email = strings.TrimSpace(in.Email)
+	in.Name = strings.TrimSpace(in.Name)
+}
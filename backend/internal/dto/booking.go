@@ -0,0 +1,31 @@
+package dto
+
+import "ride_sharing/backend/internal/models"
+
+// BookingInput is the body accepted by POST /rides/{id}/book.
+type BookingInput struct {
+	From            string `json:"from" validate:"max=255"`
+	To              string `json:"to" validate:"max=255"`
+	Date            string `json:"date" validate:"omitempty,date"`
+	Time            string `json:"time" validate:"omitempty,clock"`
+	Passengers      int    `json:"passengers" validate:"required,min=1,max=8"`
+	SpecialRequests string `json:"specialRequests" validate:"max=500"`
+}
+
+// ToModel builds a booking from the input. Ride, passenger, status and
+// timestamps are set by the handler.
+func (in *BookingInput) ToModel() models.Booking {
+	return models.Booking{
+		From:            in.From,
+		To:              in.To,
+		Date:            in.Date,
+		Time:            in.Time,
+		Passengers:      in.Passengers,
+		SpecialRequests: in.SpecialRequests,
+	}
+}
+
+// ReconfirmBookingInput is the body accepted by POST /bookings/{id}/reconfirm.
+type ReconfirmBookingInput struct {
+	Accept *bool `json:"accept" validate:"required"`
+}
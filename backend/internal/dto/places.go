@@ -0,0 +1,53 @@
+package dto
+
+import "ride_sharing/backend/internal/services"
+
+// PlaceSuggestion is one autocomplete entry. PlaceID can be passed to
+// GET /places/{placeId}.
+type PlaceSuggestion struct {
+	PlaceID       string `json:"placeId"`
+	Description   string `json:"description"`
+	MainText      string `json:"mainText"`
+	SecondaryText string `json:"secondaryText"`
+}
+
+// PlaceSuggestionsResponse is the body returned by GET /places-autocomplete.
+type PlaceSuggestionsResponse struct {
+	Predictions []PlaceSuggestion `json:"predictions"`
+}
+
+// Location is a WGS84 coordinate.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// PlaceResponse is the body returned by GET /places/{placeId}.
+type PlaceResponse struct {
+	PlaceID          string   `json:"placeId"`
+	Name             string   `json:"name"`
+	FormattedAddress string   `json:"formattedAddress"`
+	Location         Location `json:"location"`
+}
+
+func NewPlaceSuggestionsResponse(predictions []services.Prediction) PlaceSuggestionsResponse {
+	resp := PlaceSuggestionsResponse{Predictions: make([]PlaceSuggestion, 0, len(predictions))}
+	for _, p := range predictions {
+		resp.Predictions = append(resp.Predictions, PlaceSuggestion{
+			PlaceID:       p.PlaceID,
+			Description:   p.Description,
+			MainText:      p.MainText,
+			SecondaryText: p.SecondaryText,
+		})
+	}
+	return resp
+}
+
+func NewPlaceResponse(place *services.Place) PlaceResponse {
+	return PlaceResponse{
+		PlaceID:          place.PlaceID,
+		Name:             place.Name,
+		FormattedAddress: place.FormattedAddress,
+		Location:         Location{Lat: place.Lat, Lng: place.Lng},
+	}
+}
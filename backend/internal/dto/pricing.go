@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"ride_sharing/backend/internal/services"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceEstimateResponse is the body returned by GET /rides/price-estimate.
+// Prices are per seat; MaxPrice is omitted when listings are not capped.
+type PriceEstimateResponse struct {
+	DistanceMeters  int              `json:"distanceMeters"`
+	DurationSeconds int              `json:"durationSeconds"`
+	SuggestedPrice  decimal.Decimal  `json:"suggestedPrice"`
+	MinPrice        decimal.Decimal  `json:"minPrice"`
+	MaxPrice        *decimal.Decimal `json:"maxPrice,omitempty"`
+	Currency        string           `json:"currency"`
+}
+
+func NewPriceEstimateResponse(route *services.RouteEstimate, fare services.FareEstimate) PriceEstimateResponse {
+	resp := PriceEstimateResponse{
+		DistanceMeters:  route.DistanceMeters,
+		DurationSeconds: int(route.Duration.Seconds()),
+		SuggestedPrice:  fare.SuggestedPrice,
+		MinPrice:        fare.MinPrice,
+		Currency:        fare.Currency,
+	}
+	if fare.MaxPrice.IsPositive() {
+		resp.MaxPrice = &fare.MaxPrice
+	}
+	return resp
+}
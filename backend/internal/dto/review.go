@@ -0,0 +1,7 @@
+package dto
+
+// ReviewInput is the body accepted by POST /bookings/{id}/review.
+type ReviewInput struct {
+	Rating  int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
@@ -0,0 +1,300 @@
+package dto
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/shopspring/decimal"
+)
+
+// CreateRideInput is the body accepted by POST /rides.
+type CreateRideInput struct {
+	From        string                `json:"from" validate:"required,max=255"`
+	To          string                `json:"to" validate:"required,max=255,nefield=From"`
+	Date        string                `json:"date" validate:"required,date"`
+	Time        string                `json:"time" validate:"required,clock"`
+	Price       decimal.Decimal       `json:"price" validate:"gt=0,lte=10000,money"` // per seat
+	Seats       int                   `json:"seats" validate:"required,min=1,max=8"`
+	VehicleID   *string               `json:"vehicleId" validate:"omitempty,uuid"` // optional until the web client picks a vehicle
+	Preferences *RidePreferencesInput `json:"preferences"`
+	BookingMode string                `json:"bookingMode" validate:"omitempty,oneof=instant approval"` // defaults to instant
+	Description string                `json:"description" validate:"max=1000"`
+}
+
+func (in *CreateRideInput) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if strings.EqualFold(strings.TrimSpace(in.From), strings.TrimSpace(in.To)) {
+		errs.Add("to", "must differ from from")
+	}
+	validateDeparture(errs, in.Date, in.Time)
+	return errs
+}
+
+// ToModel builds a new available ride for driver. Currency is set by the
+// handler once the vehicle has been checked.
+func (in *CreateRideInput) ToModel(driver, driverName string) models.Ride {
+	ride := models.Ride{
+		Preferences: in.Preferences.ToModel(),
+		BookingMode: in.BookingMode,
+		From:        strings.TrimSpace(in.From),
+		To:          strings.TrimSpace(in.To),
+		Date:        in.Date,
+		Time:        validation.NormalizeClock(in.Time),
+		Price:       in.Price,
+		Seats:       in.Seats,
+		VehicleID:   in.VehicleID,
+		Driver:      driver,
+		DriverName:  driverName,
+		Description: in.Description,
+		Status:      "available",
+	}
+	if ride.BookingMode == "" {
+		ride.BookingMode = models.BookingModeInstant
+	}
+	return ride
+}
+
+// UpdatableRideFields lists the JSON fields a driver may change through
+// PATCH /rides/{id}. Anything else in the body is rejected.
+var UpdatableRideFields = map[string]bool{
+	"from":        true,
+	"to":          true,
+	"date":        true,
+	"time":        true,
+	"price":       true,
+	"seats":       true,
+	"vehicleId":   true,
+	"preferences": true,
+	"bookingMode": true,
+	"description": true,
+}
+
+// UpdateRideInput is the body accepted by PATCH /rides/{id}. Nil fields are
+// left unchanged. Seats is the total number of seats offered, including
+// those already booked.
+type UpdateRideInput struct {
+	From        *string               `json:"from" validate:"omitempty,min=1,max=255"`
+	To          *string               `json:"to" validate:"omitempty,min=1,max=255"`
+	Date        *string               `json:"date" validate:"omitempty,date"`
+	Time        *string               `json:"time" validate:"omitempty,clock"`
+	Price       *decimal.Decimal      `json:"price" validate:"omitempty,gt=0,lte=10000,money"`
+	Seats       *int                  `json:"seats" validate:"omitempty,min=1,max=8"`
+	VehicleID   *string               `json:"vehicleId" validate:"omitempty,uuid"`
+	Preferences *RidePreferencesInput `json:"preferences"`
+	BookingMode *string               `json:"bookingMode" validate:"omitempty,oneof=instant approval"`
+	Description *string               `json:"description" validate:"omitempty,max=1000"`
+}
+
+// CheckRideUpdateFields reports any field in body that is not driver-editable.
+func CheckRideUpdateFields(body map[string]json.RawMessage) validation.Errors {
+	errs := validation.Errors{}
+	for field := range body {
+		if !UpdatableRideFields[field] {
+			errs.Add(field, "cannot be updated")
+		}
+	}
+	return errs
+}
+
+// ChangesRoute reports whether applying the input would move the ride's
+// origin, destination, date or time.
+func (in *UpdateRideInput) ChangesRoute(ride *models.Ride) bool {
+	return (in.From != nil && strings.TrimSpace(*in.From) != ride.From) ||
+		(in.To != nil && strings.TrimSpace(*in.To) != ride.To) ||
+		(in.Date != nil && *in.Date != ride.DepartureDate()) ||
+		(in.Time != nil && validation.NormalizeClock(*in.Time) != ride.DepartureClock())
+}
+
+// Endpoints returns the ride's origin and destination after applying the
+// input.
+func (in *UpdateRideInput) Endpoints(ride *models.Ride) (from, to string) {
+	from, to = ride.From, ride.To
+	if in.From != nil {
+		from = strings.TrimSpace(*in.From)
+	}
+	if in.To != nil {
+		to = strings.TrimSpace(*in.To)
+	}
+	return from, to
+}
+
+// ValidateAgainst checks the rules that depend on the ride being updated:
+// the resulting route must still have distinct endpoints and the resulting
+// departure must be in the future.
+func (in *UpdateRideInput) ValidateAgainst(ride *models.Ride) validation.Errors {
+	from, to := in.Endpoints(ride)
+	date, clock := ride.DepartureDate(), ride.DepartureClock()
+	if in.Date != nil {
+		date = *in.Date
+	}
+	if in.Time != nil {
+		clock = validation.NormalizeClock(*in.Time)
+	}
+
+	errs := validation.Errors{}
+	if strings.EqualFold(from, to) {
+		errs.Add("to", "must differ from from")
+	}
+	if in.Date != nil || in.Time != nil {
+		validateDeparture(errs, date, clock)
+	}
+	return errs
+}
+
+// Changes returns the column updates for the fields set on the input.
+// Seats is converted from total capacity to remaining seats using booked.
+func (in *UpdateRideInput) Changes(booked int) map[string]interface{} {
+	changes := map[string]interface{}{}
+	if in.From != nil {
+		changes["from"] = strings.TrimSpace(*in.From)
+	}
+	if in.To != nil {
+		changes["to"] = strings.TrimSpace(*in.To)
+	}
+	if in.Date != nil {
+		changes["date"] = *in.Date
+	}
+	if in.Time != nil {
+		changes["time"] = validation.NormalizeClock(*in.Time)
+	}
+	if in.Price != nil {
+		changes["price"] = *in.Price
+	}
+	if in.Seats != nil {
+		changes["seats"] = *in.Seats - booked
+	}
+	if in.VehicleID != nil {
+		changes["vehicle_id"] = *in.VehicleID
+	}
+	if in.Description != nil {
+		changes["description"] = *in.Description
+	}
+	if in.BookingMode != nil {
+		changes["booking_mode"] = *in.BookingMode
+	}
+	if in.Preferences != nil {
+		prefs := in.Preferences.ToModel()
+		changes["smoking_allowed"] = prefs.SmokingAllowed
+		changes["pets_allowed"] = prefs.PetsAllowed
+		changes["luggage"] = prefs.Luggage
+		changes["music"] = prefs.Music
+		changes["women_only"] = prefs.WomenOnly
+		changes["max_two_in_back"] = prefs.MaxTwoInBack
+	}
+	return changes
+}
+
+// RidePreferencesInput sets a ride's preferences. Omitted flags are false
+// and luggage defaults to medium.
+type RidePreferencesInput struct {
+	SmokingAllowed bool   `json:"smokingAllowed"`
+	PetsAllowed    bool   `json:"petsAllowed"`
+	Luggage        string `json:"luggage" validate:"omitempty,oneof=none small medium large"`
+	Music          bool   `json:"music"`
+	WomenOnly      bool   `json:"womenOnly"`
+	MaxTwoInBack   bool   `json:"maxTwoInBack"`
+}
+
+// ToModel returns the preferences, or the defaults for a nil input.
+func (in *RidePreferencesInput) ToModel() models.RidePreferences {
+	if in == nil {
+		return models.RidePreferences{Luggage: models.LuggageMedium}
+	}
+	prefs := models.RidePreferences{
+		SmokingAllowed: in.SmokingAllowed,
+		PetsAllowed:    in.PetsAllowed,
+		Luggage:        in.Luggage,
+		Music:          in.Music,
+		WomenOnly:      in.WomenOnly,
+		MaxTwoInBack:   in.MaxTwoInBack,
+	}
+	if prefs.Luggage == "" {
+		prefs.Luggage = models.LuggageMedium
+	}
+	return prefs
+}
+
+// RideFiltersInput is a saved set of search filters. Omitted flags match
+// any ride.
+type RideFiltersInput struct {
+	SmokingAllowed *bool  `json:"smokingAllowed"`
+	PetsAllowed    *bool  `json:"petsAllowed"`
+	Luggage        string `json:"luggage" validate:"omitempty,oneof=none small medium large"`
+	Music          *bool  `json:"music"`
+	WomenOnly      *bool  `json:"womenOnly"`
+	InstantBooking *bool  `json:"instantBooking"`
+	MaxTwoInBack   *bool  `json:"maxTwoInBack"`
+}
+
+func (in *RideFiltersInput) ToModel() *models.RideFilters {
+	if in == nil {
+		return nil
+	}
+	return &models.RideFilters{
+		SmokingAllowed: in.SmokingAllowed,
+		PetsAllowed:    in.PetsAllowed,
+		Luggage:        in.Luggage,
+		Music:          in.Music,
+		WomenOnly:      in.WomenOnly,
+		InstantBooking: in.InstantBooking,
+		MaxTwoInBack:   in.MaxTwoInBack,
+	}
+}
+
+// ParseRideFilters reads preference filters from search query parameters
+// named like the JSON fields, e.g. ?petsAllowed=true&luggage=large.
+func ParseRideFilters(query url.Values) (models.RideFilters, validation.Errors) {
+	errs := validation.Errors{}
+	var filters models.RideFilters
+	flags := map[string]**bool{
+		"smokingAllowed": &filters.SmokingAllowed,
+		"petsAllowed":    &filters.PetsAllowed,
+		"music":          &filters.Music,
+		"womenOnly":      &filters.WomenOnly,
+		"instantBooking": &filters.InstantBooking,
+		"maxTwoInBack":   &filters.MaxTwoInBack,
+	}
+	for name, dst := range flags {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			errs.Add(name, "must be true or false")
+			continue
+		}
+		*dst = &b
+	}
+	if luggage := query.Get("luggage"); luggage != "" {
+		if models.LuggageAtLeast(luggage) == nil {
+			errs.Add("luggage", "must be one of: "+strings.Join(models.LuggageSizes, ", "))
+		}
+		filters.Luggage = luggage
+	}
+	return filters, errs
+}
+
+// validateDeparture rejects departures in the past. Malformed values are
+// already reported by the date and clock tags.
+func validateDeparture(errs validation.Errors, date, clock string) {
+	day, err := validation.ParseDate(date)
+	if err != nil {
+		return
+	}
+	tod, err := validation.ParseClock(clock)
+	if err != nil {
+		return
+	}
+
+	departure := time.Date(day.Year(), day.Month(), day.Day(), tod.Hour(), tod.Minute(), 0, 0, time.Local)
+	if !departure.After(time.Now()) {
+		errs.Add("date", "must be in the future")
+	}
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "ride_sharing/backend/internal/models"
+
+// RideRequestInput is the body accepted by POST /rides/{id}/request.
+type RideRequestInput struct {
+	From            string `json:"from" validate:"max=255"` // defaults to the ride's
+	To              string `json:"to" validate:"max=255"`
+	Date            string `json:"date" validate:"omitempty,date"`
+	Time            string `json:"time" validate:"omitempty,clock"`
+	Passengers      int    `json:"passengers" validate:"required,min=1,max=8"`
+	SpecialRequests string `json:"specialRequests" validate:"max=500"`
+}
+
+// ToModel builds a ride request from the input. Ride, passenger, status
+// and timestamps are set by the handler.
+func (in *RideRequestInput) ToModel() models.RideRequest {
+	return models.RideRequest{
+		From:            in.From,
+		To:              in.To,
+		Date:            in.Date,
+		Time:            in.Time,
+		Passengers:      in.Passengers,
+		SpecialRequests: in.SpecialRequests,
+	}
+}
+
+// RideRequestDecisionInput is the body accepted by
+// PUT /rides/requests/{requestId}.
+type RideRequestDecisionInput struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
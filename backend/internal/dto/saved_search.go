@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/shopspring/decimal"
+)
+
+// SavedSearchInput is the body accepted by POST /me/searches. Rides match
+// when they leave between DateFrom and DateTo inclusive with at least Seats
+// seats free, at no more than MaxPrice per seat if set, and pass Filters.
+type SavedSearchInput struct {
+	From     string            `json:"from" validate:"required,max=255"`
+	To       string            `json:"to" validate:"required,max=255"`
+	DateFrom string            `json:"dateFrom" validate:"required,date"`
+	DateTo   string            `json:"dateTo" validate:"required,date"`
+	Seats    int               `json:"seats" validate:"omitempty,min=1,max=8"` // defaults to 1
+	MaxPrice *decimal.Decimal  `json:"maxPrice" validate:"omitempty,gt=0,lte=10000,money"`
+	Filters  *RideFiltersInput `json:"filters"`
+}
+
+func (in *SavedSearchInput) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if strings.EqualFold(strings.TrimSpace(in.From), strings.TrimSpace(in.To)) {
+		errs.Add("to", "must differ from from")
+	}
+	if in.DateTo < time.Now().Format(validation.DateLayout) {
+		errs.Add("dateTo", "must not be in the past")
+	} else if in.DateTo < in.DateFrom {
+		errs.Add("dateTo", "must not be before dateFrom")
+	}
+	return errs
+}
+
+// ToModel builds the saved search for userID.
+func (in *SavedSearchInput) ToModel(userID string) models.SavedSearch {
+	search := models.SavedSearch{
+		UserID:   userID,
+		From:     strings.TrimSpace(in.From),
+		To:       strings.TrimSpace(in.To),
+		DateFrom: in.DateFrom,
+		DateTo:   in.DateTo,
+		Seats:    in.Seats,
+		MaxPrice: in.MaxPrice,
+	}
+	if search.Seats == 0 {
+		search.Seats = 1
+	}
+	if filters := in.Filters.ToModel(); filters != nil {
+		search.Filters = *filters
+	}
+	return search
+}
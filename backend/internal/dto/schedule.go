@@ -0,0 +1,244 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/shopspring/decimal"
+)
+
+// CreateScheduleInput is the body accepted by POST /schedules. The ride
+// recurs on Days (MO, TU, ... SU) from StartDate, today if omitted, through
+// Until, if set, except on the dates in Exceptions.
+type CreateScheduleInput struct {
+	From        string                `json:"from" validate:"required,max=255"`
+	To          string                `json:"to" validate:"required,max=255,nefield=From"`
+	Time        string                `json:"time" validate:"required,clock"`
+	Price       decimal.Decimal       `json:"price" validate:"gt=0,lte=10000,money"` // per seat
+	Seats       int                   `json:"seats" validate:"required,min=1,max=8"`
+	VehicleID   string                `json:"vehicleId" validate:"required,uuid"`
+	Preferences *RidePreferencesInput `json:"preferences"`
+	BookingMode string                `json:"bookingMode" validate:"omitempty,oneof=instant approval"` // defaults to instant
+	Description string                `json:"description" validate:"max=1000"`
+	Days        []string              `json:"days" validate:"required,min=1,max=7,unique,dive,oneof=MO TU WE TH FR SA SU"`
+	StartDate   string                `json:"startDate" validate:"omitempty,date"`
+	Until       string                `json:"until" validate:"omitempty,date"`
+	Exceptions  []string              `json:"exceptions" validate:"max=100,unique,dive,date"`
+}
+
+func (in *CreateScheduleInput) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if strings.EqualFold(strings.TrimSpace(in.From), strings.TrimSpace(in.To)) {
+		errs.Add("to", "must differ from from")
+	}
+	today := time.Now().Format(validation.DateLayout)
+	if in.StartDate != "" && in.StartDate < today {
+		errs.Add("startDate", "must not be in the past")
+	}
+	validateUntil(errs, in.Until, in.StartDate, today)
+	return errs
+}
+
+// ToModel builds an active schedule for driver. Currency and the route
+// estimate are set by the handler.
+func (in *CreateScheduleInput) ToModel(driver, driverName string) models.RideSchedule {
+	schedule := models.RideSchedule{
+		Driver:      driver,
+		DriverName:  driverName,
+		VehicleID:   in.VehicleID,
+		From:        strings.TrimSpace(in.From),
+		To:          strings.TrimSpace(in.To),
+		Time:        validation.NormalizeClock(in.Time),
+		Price:       in.Price,
+		Seats:       in.Seats,
+		Description: in.Description,
+		Preferences: in.Preferences.ToModel(),
+		BookingMode: in.BookingMode,
+		Status:      models.ScheduleActive,
+		Days:        in.Days,
+		StartDate:   in.StartDate,
+		Exceptions:  in.Exceptions,
+	}
+	if schedule.BookingMode == "" {
+		schedule.BookingMode = models.BookingModeInstant
+	}
+	if schedule.StartDate == "" {
+		schedule.StartDate = time.Now().Format(validation.DateLayout)
+	}
+	if in.Until != "" {
+		schedule.Until = &in.Until
+	}
+	if schedule.Exceptions == nil {
+		schedule.Exceptions = []string{}
+	}
+	return schedule
+}
+
+// UpdatableScheduleFields lists the JSON fields a driver may change through
+// PATCH /schedules/{id}. Anything else in the body is rejected.
+var UpdatableScheduleFields = map[string]bool{
+	"from":        true,
+	"to":          true,
+	"time":        true,
+	"price":       true,
+	"seats":       true,
+	"vehicleId":   true,
+	"preferences": true,
+	"bookingMode": true,
+	"description": true,
+	"days":        true,
+	"until":       true,
+	"exceptions":  true,
+}
+
+// UpdateScheduleInput is the body accepted by PATCH /schedules/{id}. Nil
+// fields are left unchanged; an empty until makes the schedule open-ended.
+type UpdateScheduleInput struct {
+	From        *string               `json:"from" validate:"omitempty,min=1,max=255"`
+	To          *string               `json:"to" validate:"omitempty,min=1,max=255"`
+	Time        *string               `json:"time" validate:"omitempty,clock"`
+	Price       *decimal.Decimal      `json:"price" validate:"omitempty,gt=0,lte=10000,money"`
+	Seats       *int                  `json:"seats" validate:"omitempty,min=1,max=8"`
+	VehicleID   *string               `json:"vehicleId" validate:"omitempty,uuid"`
+	Preferences *RidePreferencesInput `json:"preferences"`
+	BookingMode *string               `json:"bookingMode" validate:"omitempty,oneof=instant approval"`
+	Description *string               `json:"description" validate:"omitempty,max=1000"`
+	Days        []string              `json:"days" validate:"omitnil,min=1,max=7,unique,dive,oneof=MO TU WE TH FR SA SU"`
+	Until       *string               `json:"until" validate:"omitempty,date"`
+	Exceptions  []string              `json:"exceptions" validate:"omitnil,max=100,unique,dive,date"`
+}
+
+// CheckScheduleUpdateFields reports any field in body that is not
+// driver-editable.
+func CheckScheduleUpdateFields(body map[string]json.RawMessage) validation.Errors {
+	errs := validation.Errors{}
+	for field := range body {
+		if !UpdatableScheduleFields[field] {
+			errs.Add(field, "cannot be updated")
+		}
+	}
+	return errs
+}
+
+// ChangesRoute reports whether applying the input would move the
+// schedule's origin or destination.
+func (in *UpdateScheduleInput) ChangesRoute(schedule *models.RideSchedule) bool {
+	from, to := in.Endpoints(schedule)
+	return from != schedule.From || to != schedule.To
+}
+
+// Endpoints returns the schedule's origin and destination after applying
+// the input.
+func (in *UpdateScheduleInput) Endpoints(schedule *models.RideSchedule) (from, to string) {
+	from, to = schedule.From, schedule.To
+	if in.From != nil {
+		from = strings.TrimSpace(*in.From)
+	}
+	if in.To != nil {
+		to = strings.TrimSpace(*in.To)
+	}
+	return from, to
+}
+
+// Apply copies the fields set on the input onto schedule.
+func (in *UpdateScheduleInput) Apply(schedule *models.RideSchedule) {
+	if in.From != nil {
+		schedule.From = strings.TrimSpace(*in.From)
+	}
+	if in.To != nil {
+		schedule.To = strings.TrimSpace(*in.To)
+	}
+	if in.Time != nil {
+		schedule.Time = validation.NormalizeClock(*in.Time)
+	}
+	if in.Price != nil {
+		schedule.Price = *in.Price
+	}
+	if in.Seats != nil {
+		schedule.Seats = *in.Seats
+	}
+	if in.VehicleID != nil {
+		schedule.VehicleID = *in.VehicleID
+	}
+	if in.Preferences != nil {
+		schedule.Preferences = in.Preferences.ToModel()
+	}
+	if in.BookingMode != nil {
+		schedule.BookingMode = *in.BookingMode
+	}
+	if in.Description != nil {
+		schedule.Description = *in.Description
+	}
+	if in.Days != nil {
+		schedule.Days = in.Days
+	}
+	if in.Until != nil {
+		schedule.Until = nil
+		if *in.Until != "" {
+			until := *in.Until
+			schedule.Until = &until
+		}
+	}
+	if in.Exceptions != nil {
+		schedule.Exceptions = in.Exceptions
+	}
+}
+
+// ValidateAgainst checks the rules that depend on the schedule being
+// updated: distinct endpoints and an end date not before it starts.
+func (in *UpdateScheduleInput) ValidateAgainst(schedule *models.RideSchedule) validation.Errors {
+	from, to := schedule.From, schedule.To
+	if in.From != nil {
+		from = strings.TrimSpace(*in.From)
+	}
+	if in.To != nil {
+		to = strings.TrimSpace(*in.To)
+	}
+
+	errs := validation.Errors{}
+	if strings.EqualFold(from, to) {
+		errs.Add("to", "must differ from from")
+	}
+	if in.Until != nil {
+		validateUntil(errs, *in.Until, schedule.StartDate, time.Now().Format(validation.DateLayout))
+	}
+	return errs
+}
+
+// validateUntil rejects an end date before the schedule starts or before
+// today. Malformed dates are already reported by the date tag.
+func validateUntil(errs validation.Errors, until, start, today string) {
+	if until == "" {
+		return
+	}
+	if until < today {
+		errs.Add("until", "must not be in the past")
+	} else if start != "" && until < start {
+		errs.Add("until", "must not be before startDate")
+	}
+}
+
+// Reasons a series edit leaves an upcoming ride unchanged
+const (
+	UnchangedBooked     = "booked"
+	UnchangedCustomized = "customized"
+)
+
+// UnchangedRide is an upcoming ride a series edit did not touch.
+type UnchangedRide struct {
+	RideID string `json:"rideId"`
+	Date   string `json:"date"`
+	Reason string `json:"reason"` // booked or customized
+}
+
+// ScheduleUpdateResponse is the body returned by PATCH /schedules/{id}.
+// UnchangedRides lists the upcoming rides that kept their details because
+// they have bookings or were edited on their own.
+type ScheduleUpdateResponse struct {
+	models.RideSchedule
+	UnchangedRides []UnchangedRide `json:"unchangedRides"`
+}
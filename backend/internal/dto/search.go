@@ -0,0 +1,61 @@
+package dto
+
+// RideFacetColumns selects RideFacetCounts over a ride search query.
+const RideFacetColumns = `COUNT(*) AS total,
+	COUNT(*) FILTER (WHERE rides.smoking_allowed) AS smoking_allowed,
+	COUNT(*) FILTER (WHERE rides.pets_allowed) AS pets_allowed,
+	COUNT(*) FILTER (WHERE rides.music) AS music,
+	COUNT(*) FILTER (WHERE rides.women_only) AS women_only,
+	COUNT(*) FILTER (WHERE rides.booking_mode = 'instant') AS instant_booking,
+	COUNT(*) FILTER (WHERE rides.max_two_in_back) AS max_two_in_back,
+	COUNT(*) FILTER (WHERE rides.luggage = 'none') AS luggage_none,
+	COUNT(*) FILTER (WHERE rides.luggage = 'small') AS luggage_small,
+	COUNT(*) FILTER (WHERE rides.luggage = 'medium') AS luggage_medium,
+	COUNT(*) FILTER (WHERE rides.luggage = 'large') AS luggage_large`
+
+// RideFacetCounts is scanned from RideFacetColumns.
+type RideFacetCounts struct {
+	Total          int64
+	SmokingAllowed int64
+	PetsAllowed    int64
+	Music          int64
+	WomenOnly      int64
+	InstantBooking int64
+	MaxTwoInBack   int64
+	LuggageNone    int64
+	LuggageSmall   int64
+	LuggageMedium  int64
+	LuggageLarge   int64
+}
+
+// RideFacetsResponse is the body returned by GET /rides/find/facets: how
+// many of the matching rides have each preference set, and how many accept
+// each largest luggage size.
+type RideFacetsResponse struct {
+	Total          int64            `json:"total"`
+	SmokingAllowed int64            `json:"smokingAllowed"`
+	PetsAllowed    int64            `json:"petsAllowed"`
+	Music          int64            `json:"music"`
+	WomenOnly      int64            `json:"womenOnly"`
+	InstantBooking int64            `json:"instantBooking"`
+	MaxTwoInBack   int64            `json:"maxTwoInBack"`
+	Luggage        map[string]int64 `json:"luggage"`
+}
+
+func NewRideFacetsResponse(c RideFacetCounts) RideFacetsResponse {
+	return RideFacetsResponse{
+		Total:          c.Total,
+		SmokingAllowed: c.SmokingAllowed,
+		PetsAllowed:    c.PetsAllowed,
+		Music:          c.Music,
+		WomenOnly:      c.WomenOnly,
+		InstantBooking: c.InstantBooking,
+		MaxTwoInBack:   c.MaxTwoInBack,
+		Luggage: map[string]int64{
+			"none":   c.LuggageNone,
+			"small":  c.LuggageSmall,
+			"medium": c.LuggageMedium,
+			"large":  c.LuggageLarge,
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+)
+
+// PublicProfileResponse is the body returned by GET /users/{id}. It leaves
+// out contact details and settings.
+type PublicProfileResponse struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	ProfileImage *string   `json:"profileImage,omitempty"`
+	Thumbnail    *string   `json:"profileThumbnail,omitempty"`
+	Bio          string    `json:"bio,omitempty"`
+	Rating       *float64  `json:"rating,omitempty"`
+	RatingCount  int       `json:"ratingCount"`
+	RidesDriven  int64     `json:"ridesDriven"`
+	MemberSince  time.Time `json:"memberSince"`
+}
+
+func NewPublicProfileResponse(user *models.User, ridesDriven int64) PublicProfileResponse {
+	return PublicProfileResponse{
+		ID:           user.ID,
+		Name:         user.Name,
+		ProfileImage: user.ProfileImage,
+		Thumbnail:    user.ProfileThumbnail,
+		Bio:          user.Bio,
+		Rating:       user.RatingAverage,
+		RatingCount:  user.RatingCount,
+		RidesDriven:  ridesDriven,
+		MemberSince:  user.CreatedAt,
+	}
+}
+
+// ProfileResponse is the body returned by GET and PATCH /me.
+type ProfileResponse struct {
+	PublicProfileResponse
+	Email       string                 `json:"email"`
+	Phone       string                 `json:"phone,omitempty"`
+	Preferences models.UserPreferences `json:"preferences"`
+}
+
+func NewProfileResponse(user *models.User, ridesDriven int64) ProfileResponse {
+	return ProfileResponse{
+		PublicProfileResponse: NewPublicProfileResponse(user, ridesDriven),
+		Email:                 user.Email,
+		Phone:                 user.Phone,
+		Preferences:           user.Preferences,
+	}
+}
+
+// UpdatableProfileFields lists the JSON fields a user may change through
+// PATCH /me. Anything else in the body is rejected.
+var UpdatableProfileFields = map[string]bool{
+	"name":        true,
+	"bio":         true,
+	"phone":       true,
+	"preferences": true,
+}
+
+// UpdateProfileInput is the body accepted by PATCH /me. Nil fields are left
+// unchanged; an empty phone removes it. Preferences replace the stored ones
+// as a whole.
+type UpdateProfileInput struct {
+	Name        *string           `json:"name" validate:"omitempty,min=1,max=100"`
+	Bio         *string           `json:"bio" validate:"omitempty,max=500"`
+	Phone       *string           `json:"phone" validate:"omitempty,e164"`
+	Preferences *PreferencesInput `json:"preferences"`
+}
+
+type PreferencesInput struct {
+	Chattiness         string `json:"chattiness" validate:"omitempty,oneof=quiet some chatty"`
+	Language           string `json:"language" validate:"omitempty,bcp47_language_tag"`
+	EmailNotifications bool   `json:"emailNotifications"`
+
+	RideFilters *RideFiltersInput `json:"rideFilters"`
+}
+
+// CheckProfileUpdateFields reports any field in body that is not editable.
+func CheckProfileUpdateFields(body map[string]json.RawMessage) validation.Errors {
+	errs := validation.Errors{}
+	for field := range body {
+		if !UpdatableProfileFields[field] {
+			errs.Add(field, "cannot be updated")
+		}
+	}
+	return errs
+}
+
+func (in *UpdateProfileInput) Validate() validation.Errors {
+	errs := validation.Errors{}
+	if in.Name != nil && strings.TrimSpace(*in.Name) == "" {
+		errs.Add("name", "cannot be blank")
+	}
+	return errs
+}
+
+// Changes returns the column updates for the fields set on the input.
+func (in *UpdateProfileInput) Changes() map[string]interface{} {
+	changes := map[string]interface{}{}
+	if in.Name != nil {
+		changes["name"] = strings.TrimSpace(*in.Name)
+	}
+	if in.Bio != nil {
+		changes["bio"] = strings.TrimSpace(*in.Bio)
+	}
+	if in.Phone != nil {
+		changes["phone"] = *in.Phone
+	}
+	if in.Preferences != nil {
+		changes["preferences"] = models.UserPreferences{
+			Chattiness:         in.Preferences.Chattiness,
+			Language:           in.Preferences.Language,
+			EmailNotifications: in.Preferences.EmailNotifications,
+			RideFilters:        in.Preferences.RideFilters.ToModel(),
+		}
+	}
+	return changes
+}
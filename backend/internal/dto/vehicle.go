@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"strings"
+
+	"ride_sharing/backend/internal/models"
+)
+
+// VehicleInput is the body accepted by POST /me/vehicles and
+// PUT /me/vehicles/{id}.
+type VehicleInput struct {
+	Make      string   `json:"make" validate:"required,max=50"`
+	Model     string   `json:"model" validate:"required,max=50"`
+	Color     string   `json:"color" validate:"required,max=30"`
+	Plate     string   `json:"plate" validate:"required,max=15"`
+	Capacity  int      `json:"capacity" validate:"required,min=1,max=8"`
+	Amenities []string `json:"amenities" validate:"max=10,unique,dive,oneof=air_conditioning usb_charging wifi child_seat bike_rack ski_rack wheelchair_accessible"`
+}
+
+// Apply copies the input onto vehicle. Plates are stored upper-case
+// without surrounding spaces.
+func (in *VehicleInput) Apply(vehicle *models.Vehicle) {
+	vehicle.Make = strings.TrimSpace(in.Make)
+	vehicle.Model = strings.TrimSpace(in.Model)
+	vehicle.Color = strings.TrimSpace(in.Color)
+	vehicle.Plate = strings.ToUpper(strings.TrimSpace(in.Plate))
+	vehicle.Capacity = in.Capacity
+	vehicle.Amenities = in.Amenities
+	if vehicle.Amenities == nil {
+		vehicle.Amenities = []string{}
+	}
+}
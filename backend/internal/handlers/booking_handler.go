@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type BookingHandler struct {
+	db            *gorm.DB
+	notifications *services.NotificationService
+	payments      *services.PaymentService
+	policy        *services.CancellationPolicy
+}
+
+func NewBookingHandler(db *gorm.DB, notifications *services.NotificationService, payments *services.PaymentService, policy *services.CancellationPolicy) *BookingHandler {
+	return &BookingHandler{db: db, notifications: notifications, payments: payments, policy: policy}
+}
+
+// ReconfirmBooking lets a passenger accept a changed ride or cancel their
+// booking, releasing its seats.
+func (h *BookingHandler) ReconfirmBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, _ := auth.UserFromContext(r.Context())
+
+	var input dto.ReconfirmBookingInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+		return
+	}
+
+	ride, booking, ok := h.lockBooking(w, tx, id, "Failed to process booking")
+	if !ok {
+		return
+	}
+
+	if booking.PassengerID != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the passenger can reconfirm this booking", http.StatusForbidden)
+		return
+	}
+
+	if booking.Status != models.BookingNeedsReconfirmation {
+		tx.Rollback()
+		http.Error(w, "Booking does not need reconfirmation", http.StatusBadRequest)
+		return
+	}
+
+	notification := &models.Notification{
+		UserID:    ride.Driver,
+		RideID:    ride.ID,
+		BookingID: booking.ID,
+	}
+	if *input.Accept {
+		booking.Status = models.BookingConfirmed
+		booking.ReconfirmBy = nil
+		booking.UpdatedAt = time.Now()
+		if err := tx.Save(booking).Error; err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to update booking", "error", err)
+			http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+			return
+		}
+		notification.Type = models.NotificationBookingAccepted
+		notification.Message = "A passenger accepted the changes to your ride."
+	} else {
+		if err := services.CancelBooking(tx, h.payments, h.policy, ride, booking, services.CancelledByRideChange); err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to cancel booking", "error", err)
+			http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+			return
+		}
+		notification.Type = models.NotificationBookingCancelled
+		notification.Message = "A passenger cancelled their booking after your ride changed."
+	}
+
+	if err := h.notifications.Notify(tx, notification); err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to notify driver", "error", err)
+		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "booking reconfirmation handled", "booking_id", booking.ID, "status", booking.Status)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(booking)
+}
+
+// CancelBooking lets a passenger cancel their booking. The refund follows
+// the cancellation policy and is reported on the returned booking.
+func (h *BookingHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, _ := auth.UserFromContext(r.Context())
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to cancel booking", http.StatusInternalServerError)
+		return
+	}
+
+	ride, booking, ok := h.lockBooking(w, tx, id, "Failed to cancel booking")
+	if !ok {
+		return
+	}
+
+	if booking.PassengerID != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the passenger can cancel this booking", http.StatusForbidden)
+		return
+	}
+	if !booking.HoldsSeats() {
+		tx.Rollback()
+		http.Error(w, "Booking is already "+booking.Status, http.StatusConflict)
+		return
+	}
+	if departure, err := ride.Departure(); err == nil && !departure.After(time.Now()) {
+		tx.Rollback()
+		http.Error(w, "Ride has already departed", http.StatusConflict)
+		return
+	}
+
+	if err := services.CancelBooking(tx, h.payments, h.policy, ride, booking, services.CancelledByPassenger); err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to cancel booking", "booking_id", id, "error", err)
+		http.Error(w, "Failed to cancel booking", http.StatusInternalServerError)
+		return
+	}
+	if err := h.notifications.Notify(tx, &models.Notification{
+		UserID:    ride.Driver,
+		Type:      models.NotificationBookingCancelled,
+		Message:   "A passenger cancelled their booking on your ride.",
+		RideID:    ride.ID,
+		BookingID: booking.ID,
+	}); err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to notify driver", "error", err)
+		http.Error(w, "Failed to cancel booking", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to cancel booking", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "booking cancelled", "booking_id", id, "reason", booking.CancellationReason)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(booking)
+}
+
+// MarkNoShow lets the driver record, after departure, that a passenger did
+// not turn up. The passenger is not refunded.
+func (h *BookingHandler) MarkNoShow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, _ := auth.UserFromContext(r.Context())
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to update booking", http.StatusInternalServerError)
+		return
+	}
+
+	ride, booking, ok := h.lockBooking(w, tx, id, "Failed to update booking")
+	if !ok {
+		return
+	}
+
+	if ride.Driver != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the driver can report a no-show", http.StatusForbidden)
+		return
+	}
+	if departure, err := ride.Departure(); err == nil && departure.After(time.Now()) {
+		tx.Rollback()
+		http.Error(w, "Ride has not departed yet", http.StatusConflict)
+		return
+	}
+	if !booking.HoldsSeats() {
+		tx.Rollback()
+		http.Error(w, "Booking is already "+booking.Status, http.StatusConflict)
+		return
+	}
+
+	if err := services.MarkNoShow(tx, h.payments, h.policy, ride, booking); err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to mark no-show", "booking_id", id, "error", err)
+		http.Error(w, "Failed to update booking", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to update booking", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "booking marked no-show", "booking_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(booking)
+}
+
+// lockBooking loads a booking and its ride for update, writing the error
+// response and rolling back if it cannot.
+func (h *BookingHandler) lockBooking(w http.ResponseWriter, tx *gorm.DB, id, failure string) (*models.Ride, *models.Booking, bool) {
+	ride, booking, err := services.LockBooking(tx, id)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(tx.Statement.Context, "failed to find booking", "booking_id", id, "error", err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if booking == nil {
+		tx.Rollback()
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return ride, booking, true
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/services"
+)
+
+type EarningsHandler struct {
+	ledger *services.LedgerService
+}
+
+func NewEarningsHandler(ledger *services.LedgerService) *EarningsHandler {
+	return &EarningsHandler{ledger: ledger}
+}
+
+// GetEarnings returns the driver's ledger balances, one per currency, with a
+// per-ride breakdown.
+func (h *EarningsHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	earnings, err := h.ledger.Earnings(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get earnings", "error", err)
+		http.Error(w, "Failed to get earnings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(earnings)
+}
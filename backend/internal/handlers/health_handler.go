@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ReadinessChecker reports whether a dependency can serve traffic.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db           ReadinessChecker
+	shuttingDown atomic.Bool
+}
+
+func NewHealthHandler(db ReadinessChecker) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// SetShuttingDown makes /readyz fail so load balancers stop routing new
+// requests while in-flight ones drain.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz reports that the process is up.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the server can handle requests: it is not shutting
+// down and the database is reachable and migrated.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := h.db.Ready(ctx); err != nil {
+		slog.WarnContext(ctx, "readiness check failed", "error", err)
+		writeStatus(w, http.StatusServiceUnavailable, map[string]string{
+			"status":   "unavailable",
+			"database": err.Error(),
+		})
+		return
+	}
+
+	writeStatus(w, http.StatusOK, map[string]string{"status": "ready", "database": "ok"})
+}
+
+func writeStatus(w http.ResponseWriter, status int, body map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
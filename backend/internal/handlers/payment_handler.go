@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"ride_sharing/backend/internal/services"
+)
+
+type PaymentHandler struct {
+	payments *services.PaymentService
+}
+
+func NewPaymentHandler(payments *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{payments: payments}
+}
+
+// Webhook receives payment events from the gateway. Redelivered events are
+// acknowledged without being applied again.
+func (h *PaymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	event, err := h.payments.ParseWebhook(r)
+	if err != nil {
+		slog.WarnContext(ctx, "rejected payment webhook", "error", err)
+		http.Error(w, "Invalid webhook", http.StatusBadRequest)
+		return
+	}
+
+	duplicate, err := h.payments.HandleWebhook(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to handle payment webhook", "event_type", event.Type, "error", err)
+		http.Error(w, "Failed to handle webhook", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "payment webhook handled", "event_type", event.Type, "duplicate", duplicate)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writePaymentError reports a failed authorization as 402 when the payer
+// was declined and as a server error otherwise.
+func writePaymentError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if errors.Is(err, services.ErrPaymentDeclined) {
+		http.Error(w, "Payment was declined", http.StatusPaymentRequired)
+		return
+	}
+	slog.ErrorContext(r.Context(), "payment failed", "error", err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
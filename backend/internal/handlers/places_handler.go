@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Limits on client-supplied places parameters
+const (
+	maxAutocompleteInput = 200
+	maxSessionToken      = 64
+	maxPlaceID           = 256
+)
+
+type PlacesHandler struct {
+	placesService *services.PlacesService
+}
+
+func NewPlacesHandler(placesService *services.PlacesService) *PlacesHandler {
+	return &PlacesHandler{placesService: placesService}
+}
+
+func (h *PlacesHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	input := r.URL.Query().Get("input")
+	if input == "" {
+		http.Error(w, "Missing input parameter", http.StatusBadRequest)
+		return
+	}
+	if len(input) > maxAutocompleteInput {
+		http.Error(w, "Input parameter is too long", http.StatusBadRequest)
+		return
+	}
+	sessionToken := r.URL.Query().Get("sessionToken")
+	if len(sessionToken) > maxSessionToken {
+		http.Error(w, "Session token is too long", http.StatusBadRequest)
+		return
+	}
+
+	predictions, err := h.placesService.Autocomplete(r.Context(), input, sessionToken)
+	if err != nil {
+		writePlacesError(w, r, err, "Failed to fetch suggestions")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewPlaceSuggestionsResponse(predictions))
+}
+
+// GetPlace resolves an autocomplete suggestion to coordinates and a
+// formatted address.
+func (h *PlacesHandler) GetPlace(w http.ResponseWriter, r *http.Request) {
+	placeID := mux.Vars(r)["placeId"]
+	if len(placeID) > maxPlaceID {
+		http.Error(w, "Place ID is too long", http.StatusBadRequest)
+		return
+	}
+	sessionToken := r.URL.Query().Get("sessionToken")
+	if len(sessionToken) > maxSessionToken {
+		http.Error(w, "Session token is too long", http.StatusBadRequest)
+		return
+	}
+
+	place, err := h.placesService.PlaceDetails(r.Context(), placeID, sessionToken)
+	if err != nil {
+		writePlacesError(w, r, err, "Failed to fetch place")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewPlaceResponse(place))
+}
+
+// writePlacesError maps provider errors to responses without exposing
+// upstream details.
+func writePlacesError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The client moved on, e.g. to the next keystroke
+		return
+	case errors.Is(err, services.ErrPlaceNotFound):
+		http.Error(w, "Place not found", http.StatusNotFound)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "places lookup failed", "error", err)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, "Place search timed out", http.StatusGatewayTimeout)
+	case errors.Is(err, services.ErrPlacesQuotaExceeded):
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Place search is busy, try again shortly", http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrPlacesDenied):
+		http.Error(w, "Place search is unavailable", http.StatusServiceUnavailable)
+	default:
+		http.Error(w, message, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type ProfileHandler struct {
+	db     *gorm.DB
+	users  *models.UserRepository
+	photos *services.PhotoService
+}
+
+func NewProfileHandler(db *gorm.DB, users *models.UserRepository, photos *services.PhotoService) *ProfileHandler {
+	return &ProfileHandler{db: db, users: users, photos: photos}
+}
+
+// GetUser returns the public profile of any user.
+func (h *ProfileHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	user, err := h.users.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get user", "user_id", id, "error", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	ridesDriven, err := h.ridesDriven(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count rides driven", "user_id", id, "error", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewPublicProfileResponse(user, ridesDriven))
+}
+
+// GetMe returns the authenticated user's full profile.
+func (h *ProfileHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, _ := auth.UserFromContext(ctx)
+
+	user, err := h.users.GetByID(current.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get user", "error", err)
+		http.Error(w, "Failed to get profile", http.StatusInternalServerError)
+		return
+	}
+	h.writeProfile(w, r, user)
+}
+
+// UpdateMe changes the authenticated user's name, bio, phone or
+// preferences.
+func (h *ProfileHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, _ := auth.UserFromContext(ctx)
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if errs := dto.CheckProfileUpdateFields(body); len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	var input dto.UpdateProfileInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	user, err := h.users.Update(current.ID, input.Changes())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update profile", "error", err)
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "profile updated", "user_id", user.ID)
+	h.writeProfile(w, r, user)
+}
+
+// UploadPhoto replaces the authenticated user's profile photo with the
+// image in the "photo" field of a multipart form.
+func (h *ProfileHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, _ := auth.UserFromContext(ctx)
+
+	// Leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, h.photos.MaxBytes()+1<<20)
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Photo is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Expected a multipart form with a photo field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	user, err := h.photos.UploadProfilePhoto(ctx, current.ID, file)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrPhotoTooLarge):
+		http.Error(w, "Photo is too large", http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, services.ErrUnsupportedPhoto):
+		http.Error(w, "Photo must be a JPEG, PNG, GIF or WebP image", http.StatusUnsupportedMediaType)
+		return
+	default:
+		slog.ErrorContext(ctx, "failed to upload photo", "error", err)
+		http.Error(w, "Failed to upload photo", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "profile photo updated", "user_id", user.ID)
+	h.writeProfile(w, r, user)
+}
+
+func (h *ProfileHandler) writeProfile(w http.ResponseWriter, r *http.Request, user *models.User) {
+	ctx := r.Context()
+	ridesDriven, err := h.ridesDriven(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count rides driven", "error", err)
+		http.Error(w, "Failed to get profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewProfileResponse(user, ridesDriven))
+}
+
+// ridesDriven counts the rides the user has completed as a driver.
+func (h *ProfileHandler) ridesDriven(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := h.db.WithContext(ctx).Model(&models.Ride{}).
+		Where("driver = ? AND status = ?", userID, models.RideCompleted).
+		Count(&count).Error
+	return count, err
+}
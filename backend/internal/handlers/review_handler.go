@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type ReviewHandler struct {
+	reviews *services.ReviewService
+}
+
+func NewReviewHandler(reviews *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviews: reviews}
+}
+
+// ReviewBooking lets the passenger or the driver of a completed booking
+// rate the other side once.
+func (h *ReviewHandler) ReviewBooking(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, _ := auth.UserFromContext(ctx)
+
+	var input dto.ReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	review, err := h.reviews.Submit(ctx, id, user.ID, input.Rating, input.Comment)
+	switch {
+	case err == nil:
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrNotBookingParty):
+		http.Error(w, "Only the passenger or driver can review this booking", http.StatusForbidden)
+		return
+	case errors.Is(err, services.ErrBookingNotCompleted):
+		http.Error(w, "Booking is not completed", http.StatusConflict)
+		return
+	case errors.Is(err, services.ErrAlreadyReviewed):
+		http.Error(w, "Booking already reviewed", http.StatusConflict)
+		return
+	default:
+		slog.ErrorContext(ctx, "failed to create review", "booking_id", id, "error", err)
+		http.Error(w, "Failed to create review", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "review created", "booking_id", id, "role", review.Role, "rating", review.Rating)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// GetUserReviews lists the reviews a user has received.
+func (h *ReviewHandler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+
+	reviews, err := h.reviews.ListForUser(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get reviews", "user_id", id, "error", err)
+		http.Error(w, "Failed to get reviews", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+}
@@ -1,131 +1,474 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/metrics"
 	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/validation"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RideHandler struct {
-	db *gorm.DB
+	db            *gorm.DB
+	notifications *services.NotificationService
+	routing       services.RoutingProvider
+	pricing       *services.PricingService
+	payments      *services.PaymentService
+	policy        *services.CancellationPolicy
+	searches      *services.SavedSearchService
 }
 
-func NewRideHandler(db *gorm.DB) *RideHandler {
-	return &RideHandler{db: db}
+func NewRideHandler(db *gorm.DB, notifications *services.NotificationService, routing services.RoutingProvider, pricing *services.PricingService, payments *services.PaymentService, policy *services.CancellationPolicy, searches *services.SavedSearchService) *RideHandler {
+	return &RideHandler{db: db, notifications: notifications, routing: routing, pricing: pricing, payments: payments, policy: policy, searches: searches}
 }
 
 func (h *RideHandler) CreateRide(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received POST request to create ride")
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
 
-	var ride models.Ride
-	if err := json.NewDecoder(r.Body).Decode(&ride); err != nil {
-		log.Printf("Error decoding request body: %v", err)
+	var input dto.CreateRideInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
 
-	// Validate date and time
-	if ride.Date == "" {
-		http.Error(w, "Date is required", http.StatusBadRequest)
+	ride := input.ToModel(user.ID, user.Name)
+	ride.Currency = h.pricing.Currency()
+	h.estimateRoute(ctx, &ride)
+	if errs := h.checkPrice(ride.Price, routeOf(&ride)); len(errs) > 0 {
+		validation.WriteError(w, errs)
 		return
 	}
-	if ride.Time == "" {
-		http.Error(w, "Time is required", http.StatusBadRequest)
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to create ride", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Attempting to create ride: %+v", ride)
-	if err := h.db.Create(&ride).Error; err != nil {
-		log.Printf("Error creating ride: %v", err)
+	var vehicle *models.Vehicle
+	if input.VehicleID != nil {
+		var errs validation.Errors
+		var err error
+		vehicle, errs, err = checkVehicle(tx, *input.VehicleID, user.ID, input.Seats)
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to get vehicle", "error", err)
+			http.Error(w, "Failed to create ride", http.StatusInternalServerError)
+			return
+		}
+		if len(errs) > 0 {
+			tx.Rollback()
+			validation.WriteError(w, errs)
+			return
+		}
+	}
+
+	if err := tx.Create(&ride).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to create ride", "error", err)
 		http.Error(w, "Failed to create ride", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully created ride with ID: %s", ride.ID)
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to create ride", http.StatusInternalServerError)
+		return
+	}
+	ride.Vehicle = vehicle
+	h.alertSavedSearches(ctx, ride.ID)
+
+	metrics.RidesCreated.Inc()
+	slog.InfoContext(ctx, "ride created", "ride_id", ride.ID)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(ride)
 }
 
 func (h *RideHandler) GetRides(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received GET request for recent rides")
+	ctx := r.Context()
 
 	var rides []models.Ride
-	if err := h.db.Order("created_at DESC").Limit(6).Find(&rides).Error; err != nil {
-		log.Printf("Error getting recent rides: %v", err)
+	if err := h.db.WithContext(ctx).Where("status = ?", models.RideAvailable).Order("created_at DESC").Limit(6).Find(&rides).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to get recent rides", "error", err)
 		http.Error(w, "Failed to get rides", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully retrieved %d recent rides", len(rides))
+	slog.DebugContext(ctx, "retrieved recent rides", "count", len(rides))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(rides)
 }
 
 func (h *RideHandler) GetRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	id := vars["id"]
-	log.Printf("Received GET request for ride ID: %s", id)
 
 	var ride models.Ride
-	if err := h.db.First(&ride, "id = ?", id).Error; err != nil {
-		log.Printf("Error getting ride %s: %v", id, err)
+	if err := h.db.WithContext(ctx).Preload("Vehicle").First(&ride, "id = ?", id).Error; err != nil {
+		slog.WarnContext(ctx, "failed to get ride", "ride_id", id, "error", err)
 		http.Error(w, "Ride not found", http.StatusNotFound)
 		return
 	}
 
-	log.Printf("Successfully retrieved ride: %+v", ride)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ride)
 }
 
 func (h *RideHandler) UpdateRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	id := vars["id"]
-	log.Printf("Received PUT request for ride ID: %s", id)
 
-	var ride models.Ride
-	if err := json.NewDecoder(r.Body).Decode(&ride); err != nil {
-		log.Printf("Error decoding request body: %v", err)
+	user, _ := auth.UserFromContext(r.Context())
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	ride.ID = id
-	log.Printf("Attempting to update ride: %+v", ride)
-	if err := h.db.Updates(&ride).Error; err != nil {
-		log.Printf("Error updating ride %s: %v", id, err)
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if errs := dto.CheckRideUpdateFields(body); len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	var input dto.UpdateRideInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	// Estimate a moved route before the transaction so the ride is not
+	// locked while the routing provider is called
+	var moved *models.Ride
+	if input.From != nil || input.To != nil {
+		var current models.Ride
+		if err := h.db.WithContext(ctx).First(&current, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, "Ride not found", http.StatusNotFound)
+				return
+			}
+			slog.WarnContext(ctx, "failed to get ride", "ride_id", id, "error", err)
+			http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+			return
+		}
+		if from, to := input.Endpoints(&current); from != current.From || to != current.To {
+			moved = &models.Ride{ID: current.ID, From: from, To: to}
+			h.estimateRoute(ctx, moved)
+		}
+	}
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+		return
+	}
+
+	var ride models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.WarnContext(ctx, "failed to get ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+		return
+	}
+
+	if ride.Driver != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the driver can update this ride", http.StatusForbidden)
+		return
+	}
+
+	if errs := input.ValidateAgainst(&ride); len(errs) > 0 {
+		tx.Rollback()
+		validation.WriteError(w, errs)
+		return
+	}
+
+	booked, err := bookedSeats(tx, ride.ID)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to count booked seats", "ride_id", id, "error", err)
 		http.Error(w, "Failed to update ride", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully updated ride %s", id)
+	from, to := input.Endpoints(&ride)
+	endpointsMoved := from != ride.From || to != ride.To
+	if endpointsMoved && (moved == nil || moved.From != from || moved.To != to) {
+		tx.Rollback()
+		http.Error(w, "Ride was changed by another request, please retry", http.StatusConflict)
+		return
+	}
+
+	// The resulting price must fit the resulting route
+	if input.Price != nil || endpointsMoved {
+		price, route := ride.Price, routeOf(&ride)
+		if input.Price != nil {
+			price = *input.Price
+		}
+		if endpointsMoved {
+			route = routeOf(moved)
+		}
+		if errs := h.checkPrice(price, route); len(errs) > 0 {
+			tx.Rollback()
+			validation.WriteError(w, errs)
+			return
+		}
+	}
+
+	if input.Seats != nil && *input.Seats < booked {
+		tx.Rollback()
+		validation.WriteError(w, validation.Errors{
+			"seats": fmt.Sprintf("must be at least %d, the number of seats already booked", booked),
+		})
+		return
+	}
+
+	// The seats offered must still fit the ride's vehicle, new or current
+	vehicleID := ride.VehicleID
+	if input.VehicleID != nil {
+		vehicleID = input.VehicleID
+	}
+	if vehicleID != nil {
+		seats := booked + ride.Seats
+		if input.Seats != nil {
+			seats = *input.Seats
+		}
+		_, errs, err := checkVehicle(tx, *vehicleID, ride.Driver, seats)
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to get vehicle", "ride_id", id, "error", err)
+			http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+			return
+		}
+		if len(errs) > 0 {
+			tx.Rollback()
+			validation.WriteError(w, errs)
+			return
+		}
+	}
+
+	routeChanged := booked > 0 && input.ChangesRoute(&ride)
+
+	changes := input.Changes(booked)
+	if seats, ok := changes["seats"].(int); ok {
+		if seats == 0 {
+			changes["status"] = models.RideFull
+		} else if ride.Status == models.RideFull {
+			changes["status"] = models.RideAvailable
+		}
+	}
+	if endpointsMoved {
+		changes["distance_meters"] = moved.DistanceMeters
+		changes["duration_seconds"] = moved.DurationSeconds
+	}
+	if len(changes) > 0 {
+		changes["updated_at"] = time.Now()
+		if ride.ScheduleID != nil {
+			changes["customized"] = true
+		}
+		if err := tx.Model(&ride).Updates(changes).Error; err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to update ride", "ride_id", id, "error", err)
+			http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Passengers agreed to the old route and time, so ask them again
+	if routeChanged {
+		if err := h.requestReconfirmation(tx, &ride); err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to request reconfirmation", "ride_id", id, "error", err)
+			http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.db.WithContext(ctx).Preload("Vehicle").First(&ride, "id = ?", id).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to reload ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to update ride", http.StatusInternalServerError)
+		return
+	}
+	h.alertSavedSearches(ctx, ride.ID)
+
+	slog.InfoContext(ctx, "ride updated", "ride_id", id)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ride)
 }
 
+// DeleteRide lets the driver cancel a ride. Passengers are refunded in full
+// and notified; the ride is kept with status cancelled.
 func (h *RideHandler) DeleteRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	id := vars["id"]
-	log.Printf("Received DELETE request for ride ID: %s", id)
 
-	if err := h.db.Delete(&models.Ride{}, "id = ?", id).Error; err != nil {
-		log.Printf("Error deleting ride %s: %v", id, err)
+	user, _ := auth.UserFromContext(r.Context())
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
 		http.Error(w, "Failed to delete ride", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully deleted ride %s", id)
+	var ride models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to delete ride", http.StatusInternalServerError)
+		return
+	}
+
+	if ride.Driver != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the driver can delete this ride", http.StatusForbidden)
+		return
+	}
+	if ride.Status == models.RideCompleted || ride.Status == models.RideCancelled {
+		tx.Rollback()
+		http.Error(w, "Ride is already "+ride.Status, http.StatusConflict)
+		return
+	}
+
+	cancelled, err := services.CancelRide(tx, h.payments, h.policy, h.notifications, &ride)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to cancel ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to delete ride", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to delete ride", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "ride cancelled", "ride_id", id, "bookings", cancelled)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sortParam := r.URL.Query().Get("sort")
+
+	// Validate sort parameter
+	if sortParam != "" && sortParam != "rating" {
+		http.Error(w, "Invalid sort parameter", http.StatusBadRequest)
+		return
+	}
+
+	query, ok := h.searchQuery(w, r)
+	if !ok {
+		return
+	}
+
+	var rides []models.Ride
+	query = query.
+		Select("rides.*, users.rating_average AS driver_rating, users.rating_count AS driver_rating_count").
+		Joins("LEFT JOIN users ON users.id = rides.driver").
+		Preload("Vehicle")
+
+	// Best rated drivers first, unrated drivers last
+	if sortParam == "rating" {
+		query = query.Order("users.rating_average DESC NULLS LAST").Order("users.rating_count DESC")
+	}
+
+	if err := query.Find(&rides).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to find rides", "error", err)
+		http.Error(w, "Failed to find rides", http.StatusInternalServerError)
+		return
+	}
+
+	slog.DebugContext(ctx, "found matching rides", "count", len(rides))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rides)
+}
+
+// FindRideFacets counts the rides a search matches by preference, taking
+// the same parameters as FindRides.
+func (h *RideHandler) FindRideFacets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query, ok := h.searchQuery(w, r)
+	if !ok {
+		return
+	}
+
+	var counts dto.RideFacetCounts
+	if err := query.Select(dto.RideFacetColumns).Scan(&counts).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to count ride facets", "error", err)
+		http.Error(w, "Failed to find rides", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewRideFacetsResponse(counts))
+}
+
+// searchQuery builds the ride query for a search request's parameters,
+// writing a 400 response if they are invalid.
+func (h *RideHandler) searchQuery(w http.ResponseWriter, r *http.Request) (*gorm.DB, bool) {
+	ctx := r.Context()
 	from := r.URL.Query().Get("from")
 	to := r.URL.Query().Get("to")
 	date := r.URL.Query().Get("date")
@@ -133,19 +476,13 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 	seatsParam := r.URL.Query().Get("seats")
 	maxPriceParam := r.URL.Query().Get("maxPrice")
 
-	// Log all search parameters
-	log.Printf("Search Parameters:")
-	log.Printf("- From: %s", from)
-	log.Printf("- To: %s", to)
-	log.Printf("- Date: %s", date)
-	log.Printf("- Time: %s", timeParam)
-	log.Printf("- Seats: %s", seatsParam)
-	log.Printf("- MaxPrice: %s", maxPriceParam)
+	slog.DebugContext(ctx, "searching rides", "from", from, "to", to, "date", date, "time", timeParam,
+		"seats", seatsParam, "max_price", maxPriceParam)
 
 	// Validate required parameters
 	if from == "" || to == "" || date == "" {
 		http.Error(w, "Missing from, to, or date parameter", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	// Validate seats parameter
@@ -153,31 +490,38 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 	if seatsParam != "" {
 		if _, err := fmt.Sscanf(seatsParam, "%d", &seats); err != nil {
 			http.Error(w, "Invalid seats parameter", http.StatusBadRequest)
-			return
+			return nil, false
 		}
 		if seats < 1 {
 			http.Error(w, "Seats must be at least 1", http.StatusBadRequest)
-			return
+			return nil, false
 		}
-		log.Printf("Validated seats: %d", seats)
 	}
 
 	// Validate maxPrice parameter
-	var maxPrice float64
+	var maxPrice decimal.Decimal
 	if maxPriceParam != "" {
-		if _, err := fmt.Sscanf(maxPriceParam, "%f", &maxPrice); err != nil {
+		var err error
+		if maxPrice, err = decimal.NewFromString(maxPriceParam); err != nil {
 			http.Error(w, "Invalid maxPrice parameter", http.StatusBadRequest)
-			return
+			return nil, false
 		}
-		if maxPrice < 0 {
+		if maxPrice.IsNegative() {
 			http.Error(w, "MaxPrice cannot be negative", http.StatusBadRequest)
-			return
+			return nil, false
 		}
-		log.Printf("Validated maxPrice: %.2f", maxPrice)
 	}
 
-	var rides []models.Ride
-	query := h.db.Where("\"from\" COLLATE \"C\" = ? AND \"to\" COLLATE \"C\" = ?", from, to)
+	// Validate preference filters
+	filters, errs := dto.ParseRideFilters(r.URL.Query())
+	if len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return nil, false
+	}
+
+	query := h.db.WithContext(ctx).Model(&models.Ride{}).
+		Where("\"from\" COLLATE \"C\" = ? AND \"to\" COLLATE \"C\" = ?", from, to).
+		Where("status = ?", models.RideAvailable)
 
 	var nextDayStr string
 	// Handle date filtering for current and next day
@@ -185,9 +529,8 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 		// Parse the search date
 		searchDate, err := time.Parse("2006-01-02", date)
 		if err != nil {
-			log.Printf("Error parsing date: %v", err)
 			http.Error(w, "Invalid date format", http.StatusBadRequest)
-			return
+			return nil, false
 		}
 
 		// Calculate next day
@@ -196,7 +539,6 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 
 		// Filter for both current and next day
 		query = query.Where("date IN (?, ?)", date, nextDayStr)
-		log.Printf("Filtering for dates: %s and %s", date, nextDayStr)
 	}
 
 	// Handle time filtering
@@ -204,7 +546,6 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 		// For current day: show rides after the search time
 		// For next day: show all rides
 		query = query.Where("(date = ? AND time >= ?) OR date = ?", date, timeParam, nextDayStr)
-		log.Printf("Filtering for time >= %s on current date (%s), all times for next day (%s)", timeParam, date, nextDayStr)
 	}
 
 	if seatsParam != "" {
@@ -215,63 +556,55 @@ func (h *RideHandler) FindRides(w http.ResponseWriter, r *http.Request) {
 		query = query.Where("price <= ?", maxPrice)
 	}
 
-	if err := query.Find(&rides).Error; err != nil {
-		log.Printf("Error finding rides: %v", err)
-		http.Error(w, "Failed to find rides", http.StatusInternalServerError)
-		return
-	}
-
-	log.Printf("Found %d rides matching criteria", len(rides))
-
-	// Log details of each matching ride
-	for i, ride := range rides {
-		log.Printf("\nMatching Ride #%d:", i+1)
-		log.Printf("From: %s → To: %s", ride.From, ride.To)
-		log.Printf("Date & Time: %s, %s", ride.Date, ride.Time)
-		log.Printf("Driver: %s", ride.Driver)
-		log.Printf("Available Seats: %d", ride.Seats)
-		log.Printf("Price: $%.2f", ride.Price)
-		log.Printf("Status: %s", ride.Status)
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(rides)
+	return applyRideFilters(query, filters), true
 }
 
 func (h *RideHandler) BookRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	rideId := vars["id"]
-	log.Printf("Received POST request to book ride ID: %s", rideId)
 
 	// Start a transaction
-	tx := h.db.Begin()
+	tx := h.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
-		log.Printf("Error starting transaction: %v", tx.Error)
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
 		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
 		return
 	}
 
-	// Get the ride
+	// Lock the ride so concurrent bookings cannot both take its last seats
 	var ride models.Ride
-	if err := tx.First(&ride, "id = ?", rideId).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", rideId).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error finding ride: %v", err)
-		http.Error(w, "Ride not found", http.StatusNotFound)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride", "ride_id", rideId, "error", err)
+		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
 		return
 	}
 
-	var booking models.Booking
-	if err := json.NewDecoder(r.Body).Decode(&booking); err != nil {
+	var input dto.BookingInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		tx.Rollback()
-		log.Printf("Error decoding request body: %v", err)
+		slog.WarnContext(ctx, "invalid request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if err := validation.Struct(&input); err != nil {
+		tx.Rollback()
+		validation.WriteError(w, err)
+		return
+	}
+	booking := input.ToModel()
+	user, _ := auth.UserFromContext(ctx)
+	booking.PassengerID = user.ID
 
 	// IMPORTANT: Check if user is trying to book their own ride
 	if booking.PassengerID == ride.Driver {
 		tx.Rollback()
-		log.Printf("Blocked attempt to book own ride: PassengerID %s matches Driver %s", booking.PassengerID, ride.Driver)
+		slog.WarnContext(ctx, "blocked attempt to book own ride", "ride_id", rideId)
 		http.Error(w, "You cannot book your own ride", http.StatusForbidden)
 		return
 	}
@@ -283,6 +616,12 @@ func (h *RideHandler) BookRide(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if ride.BookingMode == models.BookingModeApproval {
+		tx.Rollback()
+		http.Error(w, "This ride needs the driver's approval; send a ride request instead", http.StatusConflict)
+		return
+	}
+
 	// Validate number of seats and provide helpful message
 	if booking.Passengers > ride.Seats {
 		tx.Rollback()
@@ -300,86 +639,100 @@ func (h *RideHandler) BookRide(w http.ResponseWriter, r *http.Request) {
 
 	// Set booking details
 	booking.RideID = rideId
+	if booking.From == "" || booking.To == "" {
+		booking.From, booking.To = ride.From, ride.To
+	}
 	booking.Status = "confirmed"
+	h.pricing.PriceBooking(&booking, &ride)
 	booking.CreatedAt = time.Now()
 	booking.UpdatedAt = time.Now()
 
 	// Create the booking
 	if err := tx.Create(&booking).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error creating booking: %v", err)
+		slog.ErrorContext(ctx, "failed to create booking", "error", err)
 		http.Error(w, "Failed to create booking", http.StatusInternalServerError)
 		return
 	}
 
 	// Update ride seats
 	remainingSeats := ride.Seats - booking.Passengers
-	log.Printf("Updating ride seats: %d - %d = %d", ride.Seats, booking.Passengers, remainingSeats)
 
+	// A fully booked ride stays listed as full so cancellations can reopen it
+	ride.Seats = remainingSeats
 	if remainingSeats <= 0 {
-		// Move ride to history
-		rideHistory := models.RideHistory{
-			RideID:      ride.ID,
-			From:        ride.From,
-			To:          ride.To,
-			Date:        ride.Date,
-			Time:        ride.Time,
-			Price:       ride.Price,
-			Seats:       0,
-			Driver:      ride.Driver,
-			DriverName:  ride.DriverName,
-			Description: ride.Description,
-			Status:      "completed",
-			CreatedAt:   ride.CreatedAt,
-			UpdatedAt:   ride.UpdatedAt,
-			CompletedAt: time.Now(),
-		}
-
-		if err := tx.Create(&rideHistory).Error; err != nil {
-			tx.Rollback()
-			log.Printf("Error creating ride history: %v", err)
-			http.Error(w, "Failed to process booking", http.StatusInternalServerError)
-			return
-		}
+		ride.Seats = 0
+		ride.Status = models.RideFull
+	}
+	if err := tx.Save(&ride).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to update ride", "error", err)
+		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
+		return
+	}
 
-		if err := tx.Delete(&ride).Error; err != nil {
-			tx.Rollback()
-			log.Printf("Error deleting ride: %v", err)
-			http.Error(w, "Failed to process booking", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		ride.Seats = remainingSeats
-		if err := tx.Save(&ride).Error; err != nil {
-			tx.Rollback()
-			log.Printf("Error updating ride: %v", err)
-			http.Error(w, "Failed to process booking", http.StatusInternalServerError)
-			return
-		}
+	// Hold the fare until the trip is completed. This is the last step
+	// before commit, so only a failed commit leaves a hold to release.
+	payment, err := h.payments.Authorize(tx, &booking)
+	if err != nil {
+		tx.Rollback()
+		writePaymentError(w, r, err, "Failed to create booking")
+		return
 	}
 
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		h.payments.ReleaseHold(ctx, payment)
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
 		http.Error(w, "Failed to process booking", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully processed booking for ride %s", rideId)
+	metrics.BookingsConfirmed.Inc()
+	metrics.SeatsSold.Add(float64(booking.Passengers))
+	slog.InfoContext(ctx, "booking confirmed", "ride_id", rideId, "booking_id", booking.ID, "seats", booking.Passengers)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(booking)
 }
 
+// JoinRide books an instant ride or sends a ride request for one that
+// needs the driver's approval. The response is the confirmed booking or the
+// pending request, as returned by BookRide and CreateRideRequest.
+func (h *RideHandler) JoinRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	rideId := vars["id"]
+
+	var ride models.Ride
+	if err := h.db.WithContext(ctx).Select("id", "booking_mode").First(&ride, "id = ?", rideId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride", "ride_id", rideId, "error", err)
+		http.Error(w, "Failed to join ride", http.StatusInternalServerError)
+		return
+	}
+
+	// The chosen flow re-reads the ride in its own transaction, so a mode
+	// change in between is caught there with a 409
+	if ride.BookingMode == models.BookingModeApproval {
+		h.CreateRideRequest(w, r)
+		return
+	}
+	h.BookRide(w, r)
+}
+
 func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	rideId := vars["id"]
-	log.Printf("Received POST request to create ride request for ride ID: %s", rideId)
 
 	// Start a transaction
-	tx := h.db.Begin()
+	tx := h.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
-		log.Printf("Error starting transaction: %v", tx.Error)
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
 		http.Error(w, "Failed to process request", http.StatusInternalServerError)
 		return
 	}
@@ -388,7 +741,7 @@ func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request)
 	var ride models.Ride
 	if err := tx.First(&ride, "id = ?", rideId).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error finding ride: %v", err)
+		slog.WarnContext(ctx, "ride not found", "error", err)
 		http.Error(w, "Ride not found", http.StatusNotFound)
 		return
 	}
@@ -400,30 +753,32 @@ func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var request models.RideRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	if ride.BookingMode == models.BookingModeInstant {
 		tx.Rollback()
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		http.Error(w, "This ride is booked instantly; book it instead", http.StatusConflict)
 		return
 	}
 
-	// Log the full request details
-	requestJSON, _ := json.MarshalIndent(request, "", "  ")
-	log.Printf("Ride request details:\n%s", string(requestJSON))
-
-	// Check if required fields are provided
-	if request.PassengerName == "" {
-		log.Printf("Warning: PassengerName not provided in request")
-	}
-	if request.ProfilePic == "" {
-		log.Printf("Warning: ProfilePic not provided in request")
+	var input dto.RideRequestInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		tx.Rollback()
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
 	}
-	if request.From == "" {
-		log.Printf("Warning: From location not provided in request")
+	if err := validation.Struct(&input); err != nil {
+		tx.Rollback()
+		validation.WriteError(w, err)
+		return
 	}
-	if request.To == "" {
-		log.Printf("Warning: To location not provided in request")
+	request := input.ToModel()
+	user, _ := auth.UserFromContext(ctx)
+	request.PassengerID = user.ID
+
+	if request.PassengerID == ride.Driver {
+		tx.Rollback()
+		http.Error(w, "You cannot request your own ride", http.StatusForbidden)
+		return
 	}
 
 	// Validate number of seats
@@ -435,6 +790,9 @@ func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request)
 
 	// Set request details
 	request.RideID = rideId
+	if request.From == "" || request.To == "" {
+		request.From, request.To = ride.From, ride.To
+	}
 	request.Status = "pending"
 	request.CreatedAt = time.Now()
 	request.UpdatedAt = time.Now()
@@ -442,26 +800,16 @@ func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request)
 	// Create the request
 	if err := tx.Create(&request).Error; err != nil {
 		tx.Rollback()
-		log.Printf("Error creating ride request: %v", err)
+		slog.ErrorContext(ctx, "failed to create ride request", "error", err)
 		http.Error(w, "Failed to create ride request", http.StatusInternalServerError)
 		return
 	}
 
-	// Log the saved request details
-	log.Printf("Successfully created ride request for ride %s with the following details:", rideId)
-	log.Printf("PassengerID: %s", request.PassengerID)
-	log.Printf("PassengerName: %s", request.PassengerName)
-	log.Printf("ProfilePic: %s", request.ProfilePic)
-	log.Printf("From: %s", request.From)
-	log.Printf("To: %s", request.To)
-	log.Printf("Date: %s", request.Date)
-	log.Printf("Time: %s", request.Time)
-	log.Printf("Passengers: %d", request.Passengers)
-	log.Printf("Special Requests: %s", request.SpecialRequests)
+	slog.InfoContext(ctx, "ride request created", "ride_id", rideId, "ride_request_id", request.ID, "seats", request.Passengers)
 
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("Error committing transaction: %v", err)
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
 		http.Error(w, "Failed to process request", http.StatusInternalServerError)
 		return
 	}
@@ -472,37 +820,59 @@ func (h *RideHandler) CreateRideRequest(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *RideHandler) HandleRideRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	requestId := vars["requestId"]
-	log.Printf("Received PUT request to handle ride request ID: %s", requestId)
+	user, _ := auth.UserFromContext(ctx)
 
-	var input struct {
-		Status string `json:"status"` // "approved" or "rejected"
-	}
+	var input dto.RideRequestDecisionInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-
-	if input.Status != "approved" && input.Status != "rejected" {
-		http.Error(w, "Invalid status", http.StatusBadRequest)
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
 		return
 	}
 
 	// Start a transaction
-	tx := h.db.Begin()
+	tx := h.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
-		log.Printf("Error starting transaction: %v", tx.Error)
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
 		http.Error(w, "Failed to process request", http.StatusInternalServerError)
 		return
 	}
 
 	// Get the request
 	var request models.RideRequest
-	if err := tx.First(&request, "id = ?", requestId).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, "id = ?", requestId).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Request not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride request", "ride_request_id", requestId, "error", err)
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		return
+	}
+
+	// Get the ride, locked so concurrent approvals and bookings see each
+	// other's seat changes
+	var ride models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", request.RideID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride", "ride_id", request.RideID, "error", err)
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		return
+	}
+
+	if ride.Driver != user.ID {
 		tx.Rollback()
-		log.Printf("Error finding request: %v", err)
-		http.Error(w, "Request not found", http.StatusNotFound)
+		http.Error(w, "Only the driver can handle requests for this ride", http.StatusForbidden)
 		return
 	}
 
@@ -516,71 +886,44 @@ func (h *RideHandler) HandleRideRequest(w http.ResponseWriter, r *http.Request)
 	// Update request status
 	request.Status = input.Status
 	request.UpdatedAt = time.Now()
+	if err := tx.Save(&request).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to update ride request", "error", err)
+		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		return
+	}
 
+	var payment *models.Payment
 	if input.Status == "approved" {
-		// Get the ride
-		var ride models.Ride
-		if err := tx.First(&ride, "id = ?", request.RideID).Error; err != nil {
+		if ride.Status != models.RideAvailable {
 			tx.Rollback()
-			log.Printf("Error finding ride: %v", err)
-			http.Error(w, "Ride not found", http.StatusNotFound)
+			http.Error(w, "Ride is not available", http.StatusConflict)
+			return
+		}
+		if request.Passengers > ride.Seats {
+			tx.Rollback()
+			http.Error(w, "Not enough seats available", http.StatusConflict)
 			return
 		}
 
 		// Update ride seats
-		remainingSeats := ride.Seats - request.Passengers
-		if remainingSeats <= 0 {
-			// Move ride to history
-			rideHistory := models.RideHistory{
-				RideID:      ride.ID,
-				From:        ride.From,
-				To:          ride.To,
-				Date:        ride.Date,
-				Time:        ride.Time,
-				Price:       ride.Price,
-				Seats:       0,
-				Driver:      ride.Driver,
-				DriverName:  ride.DriverName,
-				Description: ride.Description,
-				Status:      "completed",
-				CreatedAt:   ride.CreatedAt,
-				UpdatedAt:   ride.UpdatedAt,
-				CompletedAt: time.Now(),
-			}
-
-			if err := tx.Create(&rideHistory).Error; err != nil {
-				tx.Rollback()
-				log.Printf("Error creating ride history: %v", err)
-				http.Error(w, "Failed to process request", http.StatusInternalServerError)
-				return
-			}
-
-			// Delete the ride
-			if err := tx.Delete(&ride).Error; err != nil {
-				tx.Rollback()
-				log.Printf("Error deleting ride: %v", err)
-				http.Error(w, "Failed to process request", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			// Update the ride
-			ride.Seats = remainingSeats
-			if err := tx.Save(&ride).Error; err != nil {
-				tx.Rollback()
-				log.Printf("Error updating ride: %v", err)
-				http.Error(w, "Failed to process request", http.StatusInternalServerError)
-				return
-			}
+		ride.Seats -= request.Passengers
+		if ride.Seats == 0 {
+			ride.Status = models.RideFull
+		}
+		if err := tx.Save(&ride).Error; err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to update ride", "error", err)
+			http.Error(w, "Failed to process request", http.StatusInternalServerError)
+			return
 		}
 
 		// Create the booking
 		booking := models.Booking{
-			RideID:        request.RideID,
-			PassengerID:   request.PassengerID,
-			PassengerName: request.PassengerName,
-			ProfilePic:    request.ProfilePic,
-			From:          request.From,
-			To:            request.To,
+			RideID:          request.RideID,
+			PassengerID:     request.PassengerID,
+			From:            request.From,
+			To:              request.To,
 			Date:            request.Date,
 			Time:            request.Time,
 			Passengers:      request.Passengers,
@@ -589,50 +932,363 @@ func (h *RideHandler) HandleRideRequest(w http.ResponseWriter, r *http.Request)
 			CreatedAt:       time.Now(),
 			UpdatedAt:       time.Now(),
 		}
+		h.pricing.PriceBooking(&booking, &ride)
 
 		if err := tx.Create(&booking).Error; err != nil {
 			tx.Rollback()
-			log.Printf("Error creating booking: %v", err)
+			slog.ErrorContext(ctx, "failed to create booking", "error", err)
 			http.Error(w, "Failed to process request", http.StatusInternalServerError)
 			return
 		}
+
+		// Hold the fare until the trip is completed. This is the last step
+		// before commit, so only a failed commit leaves a hold to release.
+		var err error
+		if payment, err = h.payments.Authorize(tx, &booking); err != nil {
+			tx.Rollback()
+			writePaymentError(w, r, err, "Failed to process request")
+			return
+		}
 	}
 
-	// Save the updated request
-	if err := tx.Save(&request).Error; err != nil {
-		tx.Rollback()
-		log.Printf("Error updating request: %v", err)
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		h.payments.ReleaseHold(ctx, payment)
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
 		http.Error(w, "Failed to process request", http.StatusInternalServerError)
 		return
 	}
 
+	metrics.RideRequestsHandled.WithLabelValues(request.Status).Inc()
+	if request.Status == "approved" {
+		metrics.BookingsConfirmed.Inc()
+		metrics.SeatsSold.Add(float64(request.Passengers))
+	}
+	slog.InfoContext(ctx, "ride request handled", "ride_request_id", requestId, "status", request.Status)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// CompleteRide lets the driver mark a departed ride as done. Passengers'
+// payments are captured and the ride is archived to history.
+func (h *RideHandler) CompleteRide(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, _ := auth.UserFromContext(r.Context())
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+		return
+	}
+
+	var ride models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ride not found", http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to get ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+		return
+	}
+
+	if ride.Driver != user.ID {
+		tx.Rollback()
+		http.Error(w, "Only the driver can complete this ride", http.StatusForbidden)
+		return
+	}
+	if ride.Status == models.RideCompleted || ride.Status == models.RideCancelled {
+		tx.Rollback()
+		http.Error(w, "Ride is already "+ride.Status, http.StatusConflict)
+		return
+	}
+	if departure, err := ride.Departure(); err == nil && departure.After(time.Now()) {
+		tx.Rollback()
+		http.Error(w, "Ride has not departed yet", http.StatusConflict)
+		return
+	}
+
+	var bookings []models.Booking
+	if err := tx.Where("ride_id = ? AND status IN ?", ride.ID,
+		[]string{models.BookingConfirmed, models.BookingNeedsReconfirmation}).Find(&bookings).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to get bookings", "ride_id", id, "error", err)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+		return
+	}
+
+	// Captures are not undone if the transaction rolls back, but repeating
+	// one succeeds, so a failed completion can simply be retried
+	now := time.Now()
+	for i := range bookings {
+		booking := &bookings[i]
+		if err := h.payments.Capture(tx, booking.ID); err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to capture payment", "booking_id", booking.ID, "error", err)
+			http.Error(w, "Failed to complete ride", http.StatusBadGateway)
+			return
+		}
+		booking.Status = models.BookingCompleted
+		booking.ReconfirmBy = nil
+		booking.UpdatedAt = now
+		if err := tx.Save(booking).Error; err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to complete booking", "booking_id", booking.ID, "error", err)
+			http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ride.Status = models.RideCompleted
+	ride.UpdatedAt = now
+	if err := tx.Save(&ride).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to update ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+		return
+	}
+
+	history := models.RideHistory{
+		RideID:      ride.ID,
+		From:        ride.From,
+		To:          ride.To,
+		Date:        ride.DepartureDate(),
+		Time:        ride.DepartureClock(),
+		Price:       ride.Price,
+		Currency:    ride.Currency,
+		Seats:       ride.Seats,
+		Driver:      ride.Driver,
+		DriverName:  ride.DriverName,
+		Description: ride.Description,
+		Status:      models.RideCompleted,
+		CompletedAt: now,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to archive ride", "ride_id", id, "error", err)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
+		return
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("Error committing transaction: %v", err)
-		http.Error(w, "Failed to process request", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to complete ride", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully handled ride request %s", requestId)
+	slog.InfoContext(ctx, "ride completed", "ride_id", id, "bookings", len(bookings))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(request)
+	json.NewEncoder(w).Encode(ride)
 }
 
+// GetPendingRequests lists the pending requests for the caller's rides.
 func (h *RideHandler) GetPendingRequests(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received GET request for pending ride requests")
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
 
 	var requests []models.RideRequest
-	if err := h.db.Where("status = ?", "pending").Order("created_at DESC").Find(&requests).Error; err != nil {
-		log.Printf("Error getting pending requests: %v", err)
+	if err := h.db.WithContext(ctx).Preload("Passenger").
+		Where("status = ? AND ride_id IN (?)", "pending", h.db.Model(&models.Ride{}).Select("id").Where("driver = ?", user.ID)).
+		Order("created_at DESC").Find(&requests).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to get pending requests", "error", err)
 		http.Error(w, "Failed to get pending requests", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Successfully retrieved %d pending requests", len(requests))
+	slog.DebugContext(ctx, "retrieved pending requests", "count", len(requests))
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(requests); err != nil {
-		log.Printf("Error encoding response: %v", err)
+		slog.ErrorContext(ctx, "failed to encode response", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
+
+// PriceEstimate suggests a per-seat fare for a route.
+func (h *RideHandler) PriceEstimate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	from := strings.TrimSpace(r.URL.Query().Get("from"))
+	to := strings.TrimSpace(r.URL.Query().Get("to"))
+
+	errs := validation.Errors{}
+	if from == "" {
+		errs.Add("from", "is required")
+	}
+	if to == "" {
+		errs.Add("to", "is required")
+	}
+	if len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	route, err := h.routing.Route(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrNoRoute) || errors.Is(err, services.ErrPlaceNotFound) {
+			http.Error(w, "No route found between from and to", http.StatusUnprocessableEntity)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to estimate route", "error", err)
+		http.Error(w, "Failed to estimate price", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.NewPriceEstimateResponse(route, h.pricing.Estimate(route)))
+}
+
+// checkPrice rejects a per-seat price above the cap for route, which may be
+// nil when the route is unknown.
+func (h *RideHandler) checkPrice(price decimal.Decimal, route *services.RouteEstimate) validation.Errors {
+	fare := h.pricing.Estimate(route)
+	if fare.MaxPrice.IsPositive() && price.GreaterThan(fare.MaxPrice) {
+		return validation.Errors{"price": fmt.Sprintf("must be at most %s for this route", fare.MaxPrice.StringFixed(2))}
+	}
+	return nil
+}
+
+// routeOf returns the ride's stored route estimate, or nil if it has none.
+func routeOf(ride *models.Ride) *services.RouteEstimate {
+	if ride.DistanceMeters == nil || ride.DurationSeconds == nil {
+		return nil
+	}
+	return &services.RouteEstimate{
+		DistanceMeters: *ride.DistanceMeters,
+		Duration:       time.Duration(*ride.DurationSeconds) * time.Second,
+	}
+}
+
+// estimateRoute fills the ride's distance and duration. Estimates are best
+// effort: the ride is saved without them if routing fails.
+func (h *RideHandler) estimateRoute(ctx context.Context, ride *models.Ride) {
+	estimate, err := h.routing.Route(ctx, ride.From, ride.To)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to estimate route", "ride_id", ride.ID, "error", err)
+		ride.DistanceMeters, ride.DurationSeconds = nil, nil
+		return
+	}
+	distance := estimate.DistanceMeters
+	duration := int(estimate.Duration.Seconds())
+	ride.DistanceMeters = &distance
+	ride.DurationSeconds = &duration
+}
+
+// requestReconfirmation flags every active booking on an updated ride as
+// needing reconfirmation before departure and notifies its passenger.
+func (h *RideHandler) requestReconfirmation(tx *gorm.DB, ride *models.Ride) error {
+	if err := tx.First(ride, "id = ?", ride.ID).Error; err != nil {
+		return err
+	}
+	departure, err := ride.Departure()
+	if err != nil {
+		return err
+	}
+
+	var bookings []models.Booking
+	if err := tx.Where("ride_id = ? AND status IN ?", ride.ID,
+		[]string{models.BookingConfirmed, models.BookingNeedsReconfirmation}).Find(&bookings).Error; err != nil {
+		return err
+	}
+
+	for i := range bookings {
+		booking := &bookings[i]
+		booking.Status = models.BookingNeedsReconfirmation
+		booking.ReconfirmBy = &departure
+		booking.UpdatedAt = time.Now()
+		if err := tx.Save(booking).Error; err != nil {
+			return err
+		}
+
+		err := h.notifications.Notify(tx, &models.Notification{
+			UserID: booking.PassengerID,
+			Type:   models.NotificationRideChanged,
+			Message: fmt.Sprintf("Your ride from %s to %s now departs on %s at %s. Please confirm or cancel your booking before departure.",
+				ride.From, ride.To, ride.DepartureDate(), ride.DepartureClock()),
+			RideID:    ride.ID,
+			BookingID: booking.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	slog.InfoContext(tx.Statement.Context, "requested booking reconfirmation", "ride_id", ride.ID, "bookings", len(bookings))
+	return nil
+}
+
+// alertSavedSearches notifies passengers whose saved searches a committed
+// ride now matches. Failures are logged; the ride change stands.
+func (h *RideHandler) alertSavedSearches(ctx context.Context, rideID string) {
+	notified, err := h.searches.MatchRide(ctx, rideID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to match saved searches", "ride_id", rideID, "error", err)
+		return
+	}
+	if notified > 0 {
+		slog.InfoContext(ctx, "alerted saved searches", "ride_id", rideID, "users", notified)
+	}
+}
+
+// checkVehicle loads the driver's vehicle and checks it can carry seats
+// passengers. Problems with the input are returned as validation errors.
+// The vehicle is locked for share, so inside a transaction its capacity
+// cannot be lowered until the caller commits.
+func checkVehicle(db *gorm.DB, vehicleID, driver string, seats int) (*models.Vehicle, validation.Errors, error) {
+	var vehicle models.Vehicle
+	if err := db.Clauses(clause.Locking{Strength: "SHARE"}).First(&vehicle, "id = ? AND owner_id = ?", vehicleID, driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, validation.Errors{"vehicleId": "must be one of the driver's vehicles"}, nil
+		}
+		return nil, nil, err
+	}
+	if seats > vehicle.Capacity {
+		return nil, validation.Errors{
+			"seats": fmt.Sprintf("must be at most %d, the vehicle's capacity", vehicle.Capacity),
+		}, nil
+	}
+	return &vehicle, nil, nil
+}
+
+// applyRideFilters narrows a ride query to rides matching the preference
+// filters.
+func applyRideFilters(query *gorm.DB, filters models.RideFilters) *gorm.DB {
+	for column, value := range map[string]*bool{
+		"rides.smoking_allowed": filters.SmokingAllowed,
+		"rides.pets_allowed":    filters.PetsAllowed,
+		"rides.music":           filters.Music,
+		"rides.women_only":      filters.WomenOnly,
+		"rides.max_two_in_back": filters.MaxTwoInBack,
+	} {
+		if value != nil {
+			query = query.Where(column+" = ?", *value)
+		}
+	}
+	if filters.Luggage != "" {
+		query = query.Where("rides.luggage IN ?", models.LuggageAtLeast(filters.Luggage))
+	}
+	if filters.InstantBooking != nil {
+		mode := models.BookingModeApproval
+		if *filters.InstantBooking {
+			mode = models.BookingModeInstant
+		}
+		query = query.Where("rides.booking_mode = ?", mode)
+	}
+	return query
+}
+
+// bookedSeats returns the number of seats held by active bookings on a ride.
+func bookedSeats(db *gorm.DB, rideID string) (int, error) {
+	var booked int
+	err := db.Model(&models.Booking{}).
+		Where("ride_id = ? AND status IN ?", rideID, []string{models.BookingConfirmed, models.BookingNeedsReconfirmation}).
+		Select("COALESCE(SUM(passengers), 0)").
+		Scan(&booked).Error
+	return booked, err
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// maxSavedSearches caps the searches one passenger can be alerted about.
+const maxSavedSearches = 20
+
+type SavedSearchHandler struct {
+	db *gorm.DB
+}
+
+func NewSavedSearchHandler(db *gorm.DB) *SavedSearchHandler {
+	return &SavedSearchHandler{db: db}
+}
+
+// GetSavedSearches lists the authenticated user's saved searches.
+func (h *SavedSearchHandler) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var searches []models.SavedSearch
+	if err := h.db.WithContext(ctx).Where("user_id = ?", user.ID).Order("created_at DESC").Find(&searches).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to get saved searches", "error", err)
+		http.Error(w, "Failed to get saved searches", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(searches)
+}
+
+// CreateSavedSearch saves a search for the authenticated user, who is then
+// notified when a ride matching it is listed or changed to match.
+func (h *SavedSearchHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var input dto.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	var count int64
+	if err := h.db.WithContext(ctx).Model(&models.SavedSearch{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to count saved searches", "error", err)
+		http.Error(w, "Failed to save search", http.StatusInternalServerError)
+		return
+	}
+	if count >= maxSavedSearches {
+		http.Error(w, "Too many saved searches", http.StatusConflict)
+		return
+	}
+
+	search := input.ToModel(user.ID)
+	search.CreatedAt = time.Now()
+	if err := h.db.WithContext(ctx).Create(&search).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to save search", "error", err)
+		http.Error(w, "Failed to save search", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "search saved", "search_id", search.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(search)
+}
+
+// DeleteSavedSearch stops alerts for one of the user's saved searches.
+func (h *SavedSearchHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	user, _ := auth.UserFromContext(ctx)
+
+	var deleted int64
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, user.ID).Delete(&models.SavedSearch{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Where("saved_search_id = ?", id).Delete(&models.SavedSearchAlert{}).Error
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete saved search", "search_id", id, "error", err)
+		http.Error(w, "Failed to delete saved search", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		http.Error(w, "Saved search not found", http.StatusNotFound)
+		return
+	}
+
+	slog.InfoContext(ctx, "saved search deleted", "search_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/services"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleHandler manages recurring rides. Single occurrences are ordinary
+// rides, edited and cancelled through the ride endpoints; the schedule
+// endpoints change the whole series. It shares the ride handler's pricing,
+// routing and cancellation dependencies.
+type ScheduleHandler struct {
+	rides     *RideHandler
+	schedules *services.ScheduleService
+}
+
+func NewScheduleHandler(rides *RideHandler, schedules *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{rides: rides, schedules: schedules}
+}
+
+// GetSchedules lists the authenticated driver's schedules.
+func (h *ScheduleHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var schedules []models.RideSchedule
+	if err := h.rides.db.WithContext(ctx).Where("driver = ?", user.ID).Order("created_at DESC").Find(&schedules).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to get schedules", "error", err)
+		http.Error(w, "Failed to get schedules", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateSchedule starts a recurring ride for the authenticated driver and
+// creates its rides up to the scheduling horizon.
+func (h *ScheduleHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var input dto.CreateScheduleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	schedule := input.ToModel(user.ID, user.Name)
+	schedule.Currency = h.rides.pricing.Currency()
+	if errs := h.estimateRoute(ctx, &schedule); len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	// Start a transaction
+	tx := h.rides.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+
+	_, errs, err := checkVehicle(tx, input.VehicleID, user.ID, input.Seats)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to get vehicle", "error", err)
+		http.Error(w, "Failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+	if len(errs) > 0 {
+		tx.Rollback()
+		validation.WriteError(w, errs)
+		return
+	}
+
+	if err := tx.Create(&schedule).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to create schedule", "error", err)
+		http.Error(w, "Failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+	created, err := h.schedules.Materialize(tx, &schedule, time.Now())
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to create scheduled rides", "schedule_id", schedule.ID, "error", err)
+		http.Error(w, "Failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+
+	h.schedules.AlertSavedSearches(ctx, created)
+
+	slog.InfoContext(ctx, "schedule created", "schedule_id", schedule.ID, "rides", len(created))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateSchedule edits the whole series. Upcoming rides without bookings
+// are brought in line with the schedule, or cancelled if their date no
+// longer recurs; rides that already have bookings or were edited on their
+// own keep their details and are listed in the response. Rides for newly
+// added dates are created.
+func (h *ScheduleHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	user, _ := auth.UserFromContext(ctx)
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if errs := dto.CheckScheduleUpdateFields(body); len(errs) > 0 {
+		validation.WriteError(w, errs)
+		return
+	}
+
+	var input dto.UpdateScheduleInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		slog.WarnContext(ctx, "invalid request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	// Estimate a moved route before the transaction so the schedule is not
+	// locked while the routing provider is called
+	var moved *models.Ride
+	if input.From != nil || input.To != nil {
+		var current models.RideSchedule
+		if err := h.rides.db.WithContext(ctx).First(&current, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, "Schedule not found", http.StatusNotFound)
+				return
+			}
+			slog.ErrorContext(ctx, "failed to get schedule", "schedule_id", id, "error", err)
+			http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+			return
+		}
+		if input.ChangesRoute(&current) {
+			from, to := input.Endpoints(&current)
+			moved = &models.Ride{From: from, To: to}
+			h.rides.estimateRoute(ctx, moved)
+		}
+	}
+
+	// Start a transaction
+	tx := h.rides.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+
+	schedule, ok := h.lockSchedule(w, tx, id, user.ID, "Failed to update schedule")
+	if !ok {
+		return
+	}
+
+	if errs := input.ValidateAgainst(schedule); len(errs) > 0 {
+		tx.Rollback()
+		validation.WriteError(w, errs)
+		return
+	}
+
+	routeChanged := input.ChangesRoute(schedule)
+	if routeChanged {
+		if from, to := input.Endpoints(schedule); moved == nil || moved.From != from || moved.To != to {
+			tx.Rollback()
+			http.Error(w, "Schedule was changed by another request, please retry", http.StatusConflict)
+			return
+		}
+	}
+	input.Apply(schedule)
+
+	if input.VehicleID != nil || input.Seats != nil {
+		_, errs, err := checkVehicle(tx, schedule.VehicleID, schedule.Driver, schedule.Seats)
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to get vehicle", "schedule_id", id, "error", err)
+			http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+			return
+		}
+		if len(errs) > 0 {
+			tx.Rollback()
+			validation.WriteError(w, errs)
+			return
+		}
+	}
+
+	if routeChanged {
+		schedule.DistanceMeters, schedule.DurationSeconds = moved.DistanceMeters, moved.DurationSeconds
+	}
+	if routeChanged || input.Price != nil {
+		if errs := h.rides.checkPrice(schedule.Price, scheduleRoute(schedule)); len(errs) > 0 {
+			tx.Rollback()
+			validation.WriteError(w, errs)
+			return
+		}
+	}
+
+	schedule.UpdatedAt = time.Now()
+	if err := tx.Save(schedule).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to update schedule", "schedule_id", id, "error", err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	rides, err := h.schedules.UpcomingRides(tx, schedule.ID, now)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to get scheduled rides", "schedule_id", id, "error", err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+
+	var changed []string
+	unchanged := []dto.UnchangedRide{}
+	for i := range rides {
+		ride := &rides[i]
+		booked, err := bookedSeats(tx, ride.ID)
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to count booked seats", "ride_id", ride.ID, "error", err)
+			http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+			return
+		}
+		if booked > 0 || ride.Customized {
+			reason := dto.UnchangedBooked
+			if booked == 0 {
+				reason = dto.UnchangedCustomized
+			}
+			unchanged = append(unchanged, dto.UnchangedRide{RideID: ride.ID, Date: ride.DepartureDate(), Reason: reason})
+			continue
+		}
+
+		day, _ := validation.ParseDate(ride.ScheduledDate())
+		if !schedule.OccursOn(day) {
+			_, err = services.CancelRide(tx, h.rides.payments, h.rides.policy, h.rides.notifications, ride)
+		} else {
+			err = tx.Model(ride).Updates(schedule.RideChanges()).Error
+			changed = append(changed, ride.ID)
+		}
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to update scheduled ride", "ride_id", ride.ID, "error", err)
+			http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	created, err := h.schedules.Materialize(tx, schedule, now)
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to create scheduled rides", "schedule_id", id, "error", err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+
+	h.schedules.AlertSavedSearches(ctx, append(changed, created...))
+
+	slog.InfoContext(ctx, "schedule updated", "schedule_id", id, "unchanged_rides", len(unchanged))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.ScheduleUpdateResponse{RideSchedule: *schedule, UnchangedRides: unchanged})
+}
+
+// CancelSchedule ends the series and cancels its upcoming rides. Booked
+// passengers are refunded in full and notified, as when a single ride is
+// cancelled.
+func (h *ScheduleHandler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	user, _ := auth.UserFromContext(ctx)
+
+	// Start a transaction
+	tx := h.rides.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+		return
+	}
+
+	schedule, ok := h.lockSchedule(w, tx, id, user.ID, "Failed to cancel schedule")
+	if !ok {
+		return
+	}
+
+	if err := tx.Model(schedule).Updates(map[string]interface{}{
+		"status":     models.ScheduleCancelled,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to cancel schedule", "schedule_id", id, "error", err)
+		http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+		return
+	}
+
+	rides, err := h.schedules.UpcomingRides(tx, schedule.ID, time.Now())
+	if err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to get scheduled rides", "schedule_id", id, "error", err)
+		http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+		return
+	}
+
+	bookings := 0
+	for i := range rides {
+		cancelled, err := services.CancelRide(tx, h.rides.payments, h.rides.policy, h.rides.notifications, &rides[i])
+		if err != nil {
+			tx.Rollback()
+			slog.ErrorContext(ctx, "failed to cancel ride", "ride_id", rides[i].ID, "error", err)
+			http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+			return
+		}
+		bookings += cancelled
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "schedule cancelled", "schedule_id", id, "rides", len(rides), "bookings", bookings)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lockSchedule loads an active schedule of driver for update, writing the
+// error response and rolling back if it cannot.
+func (h *ScheduleHandler) lockSchedule(w http.ResponseWriter, tx *gorm.DB, id, driver, failure string) (*models.RideSchedule, bool) {
+	var schedule models.RideSchedule
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, "id = ?", id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Schedule not found", http.StatusNotFound)
+			return nil, false
+		}
+		slog.ErrorContext(tx.Statement.Context, "failed to get schedule", "schedule_id", id, "error", err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return nil, false
+	}
+	if schedule.Driver != driver {
+		tx.Rollback()
+		http.Error(w, "Only the driver can change this schedule", http.StatusForbidden)
+		return nil, false
+	}
+	if schedule.Status != models.ScheduleActive {
+		tx.Rollback()
+		http.Error(w, "Schedule is already "+schedule.Status, http.StatusConflict)
+		return nil, false
+	}
+	return &schedule, true
+}
+
+// estimateRoute fills the schedule's distance and duration, best effort as
+// for rides, and checks its price against the route.
+func (h *ScheduleHandler) estimateRoute(ctx context.Context, schedule *models.RideSchedule) validation.Errors {
+	ride := models.Ride{From: schedule.From, To: schedule.To}
+	h.rides.estimateRoute(ctx, &ride)
+	schedule.DistanceMeters, schedule.DurationSeconds = ride.DistanceMeters, ride.DurationSeconds
+	return h.rides.checkPrice(schedule.Price, scheduleRoute(schedule))
+}
+
+// scheduleRoute returns the schedule's stored route estimate, or nil if it
+// has none.
+func scheduleRoute(schedule *models.RideSchedule) *services.RouteEstimate {
+	return routeOf(&models.Ride{DistanceMeters: schedule.DistanceMeters, DurationSeconds: schedule.DurationSeconds})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ride_sharing/backend/internal/auth"
+	"ride_sharing/backend/internal/dto"
+	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/validation"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VehicleHandler struct {
+	db *gorm.DB
+}
+
+func NewVehicleHandler(db *gorm.DB) *VehicleHandler {
+	return &VehicleHandler{db: db}
+}
+
+// GetVehicles lists the authenticated user's vehicles.
+func (h *VehicleHandler) GetVehicles(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var vehicles []models.Vehicle
+	if err := h.db.WithContext(ctx).Where("owner_id = ?", user.ID).Order("created_at").Find(&vehicles).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to get vehicles", "error", err)
+		http.Error(w, "Failed to get vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicles)
+}
+
+// CreateVehicle registers a vehicle for the authenticated user.
+func (h *VehicleHandler) CreateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, _ := auth.UserFromContext(ctx)
+
+	var input dto.VehicleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	vehicle := models.Vehicle{OwnerID: user.ID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	input.Apply(&vehicle)
+	if err := h.db.WithContext(ctx).Create(&vehicle).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to create vehicle", "error", err)
+		http.Error(w, "Failed to create vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "vehicle created", "vehicle_id", vehicle.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// UpdateVehicle replaces the details of one of the user's vehicles. The
+// capacity cannot drop below the seats offered on its upcoming rides.
+func (h *VehicleHandler) UpdateVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	user, _ := auth.UserFromContext(ctx)
+
+	var input dto.VehicleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := validation.Struct(&input); err != nil {
+		validation.WriteError(w, err)
+		return
+	}
+
+	// Start a transaction
+	tx := h.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		slog.ErrorContext(ctx, "failed to start transaction", "error", tx.Error)
+		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	// Lock the vehicle so no ride or schedule is offered against the old
+	// capacity while the new one is checked
+	vehicle, ok := h.ownedVehicle(w, r, tx.Clauses(clause.Locking{Strength: "UPDATE"}), id, user.ID, "Failed to update vehicle")
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	var largest int64
+	if err := tx.Model(&models.Ride{}).
+		Select("COALESCE(MAX(rides.seats + COALESCE(b.booked, 0)), 0)").
+		Joins("LEFT JOIN (SELECT ride_id, SUM(passengers) AS booked FROM bookings WHERE status IN ? GROUP BY ride_id) b ON b.ride_id = rides.id::text",
+			[]string{models.BookingConfirmed, models.BookingNeedsReconfirmation}).
+		Where("rides.vehicle_id = ? AND rides.status IN ?", vehicle.ID, []string{models.RideAvailable, models.RideFull}).
+		Scan(&largest).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to check vehicle rides", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	var largestScheduled int64
+	if err := tx.Model(&models.RideSchedule{}).
+		Select("COALESCE(MAX(seats), 0)").
+		Where("vehicle_id = ? AND status = ?", vehicle.ID, models.ScheduleActive).
+		Scan(&largestScheduled).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to check vehicle schedules", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	if int64(input.Capacity) < max(largest, largestScheduled) {
+		tx.Rollback()
+		validation.WriteError(w, validation.Errors{
+			"capacity": "must cover the seats offered on this vehicle's upcoming and recurring rides",
+		})
+		return
+	}
+
+	input.Apply(vehicle)
+	vehicle.UpdatedAt = time.Now()
+	if err := tx.Save(vehicle).Error; err != nil {
+		tx.Rollback()
+		slog.ErrorContext(ctx, "failed to update vehicle", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		slog.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vehicle)
+}
+
+// DeleteVehicle removes one of the user's vehicles unless upcoming rides
+// still use it.
+func (h *VehicleHandler) DeleteVehicle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := mux.Vars(r)["id"]
+	user, _ := auth.UserFromContext(ctx)
+
+	vehicle, ok := h.ownedVehicle(w, r, h.db.WithContext(ctx), id, user.ID, "Failed to delete vehicle")
+	if !ok {
+		return
+	}
+
+	var upcoming int64
+	if err := h.db.WithContext(ctx).Model(&models.Ride{}).
+		Where("vehicle_id = ? AND status IN ?", vehicle.ID, []string{models.RideAvailable, models.RideFull}).
+		Count(&upcoming).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to check vehicle rides", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
+		return
+	}
+	if upcoming > 0 {
+		http.Error(w, "Vehicle is used by upcoming rides", http.StatusConflict)
+		return
+	}
+
+	var schedules int64
+	if err := h.db.WithContext(ctx).Model(&models.RideSchedule{}).
+		Where("vehicle_id = ? AND status = ?", vehicle.ID, models.ScheduleActive).
+		Count(&schedules).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to check vehicle schedules", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
+		return
+	}
+	if schedules > 0 {
+		http.Error(w, "Vehicle is used by recurring rides", http.StatusConflict)
+		return
+	}
+
+	if err := h.db.WithContext(ctx).Delete(vehicle).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to delete vehicle", "vehicle_id", id, "error", err)
+		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "vehicle deleted", "vehicle_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ownedVehicle loads a vehicle belonging to ownerID through db, writing the
+// error response if there is none.
+func (h *VehicleHandler) ownedVehicle(w http.ResponseWriter, r *http.Request, db *gorm.DB, id, ownerID, failure string) (*models.Vehicle, bool) {
+	ctx := r.Context()
+	var vehicle models.Vehicle
+	if err := db.First(&vehicle, "id = ? AND owner_id = ?", id, ownerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+			return nil, false
+		}
+		slog.ErrorContext(ctx, "failed to get vehicle", "vehicle_id", id, "error", err)
+		http.Error(w, failure, http.StatusInternalServerError)
+		return nil, false
+	}
+	return &vehicle, true
+}
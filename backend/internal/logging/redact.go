@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged. Keys are
+// compared lower-cased with underscores and dashes removed.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"authorization": true,
+	"cookie":        true,
+	"secret":        true,
+	"apikey":        true,
+	"key":           true,
+	"email":         true,
+	"phone":         true,
+	"passengername": true,
+	"drivername":    true,
+	"name":          true,
+	"profilepic":    true,
+	"profileimage":  true,
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._\-]+`)
+	secretParam   = regexp.MustCompile(`(?i)\b(token|key|code|password|secret)=[^&\s"']+`)
+)
+
+// redactAttr hides values of sensitive keys and scrubs emails, bearer
+// tokens, JWTs and credential query parameters from string values and from
+// errors and Stringers, whose messages often embed request URLs.
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[normalizeKey(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Scrub(attr.Value.String()))
+	case slog.KindAny:
+		switch v := attr.Value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, Scrub(v.Error()))
+		case fmt.Stringer:
+			return slog.String(attr.Key, Scrub(v.String()))
+		}
+	}
+	return attr
+}
+
+// Scrub removes emails, tokens and credential query parameters from s.
+func Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = secretParam.ReplaceAllString(s, "$1="+redacted)
+	return emailPattern.ReplaceAllString(s, redacted)
+}
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "ride not found", "ride not found"},
+		{"email", "sent to jane.doe@example.com", "sent to [REDACTED]"},
+		{"bearer", "Authorization: Bearer abc.def-123", "Authorization: Bearer [REDACTED]"},
+		{"jwt", "token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl", "token [REDACTED]"},
+		{"query key", "GET /maps/api?input=paris&key=AIzaSecret", "GET /maps/api?input=paris&key=[REDACTED]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Scrub(tt.in); got != tt.want {
+				t.Errorf("Scrub(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestRedactAttr(t *testing.T) {
+	urlErr := &url.Error{Op: "Get", URL: "https://maps.googleapis.com/maps/api/place/autocomplete/json?input=x&key=AIzaSecret", Err: errors.New("timeout")}
+
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"sensitive key", slog.String("email", "jane@example.com"), "[REDACTED]"},
+		{"string value", slog.String("detail", "Bearer abc123"), "Bearer [REDACTED]"},
+		{"error value", slog.Any("error", urlErr), `Get "https://maps.googleapis.com/maps/api/place/autocomplete/json?input=x&key=[REDACTED]": timeout`},
+		{"stringer value", slog.Any("request", stringer("token=abc")), "token=[REDACTED]"},
+		{"other value", slog.Int("count", 3), "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAttr(nil, tt.attr).Value.String(); got != tt.want {
+				t.Errorf("redactAttr(%v) = %q, want %q", tt.attr, got, tt.want)
+			}
+		})
+	}
+}
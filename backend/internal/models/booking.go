@@ -1,20 +1,52 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Booking statuses
+const (
+	BookingConfirmed           = "confirmed"
+	BookingNeedsReconfirmation = "needs_reconfirmation"
+	BookingCancelled           = "cancelled"
+	BookingCompleted           = "completed"
+	BookingNoShow              = "no_show"
+)
 
 type Booking struct {
-    ID              string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-    RideID          string    `json:"rideId"`
-    PassengerID     string    `json:"passengerId"`
-    PassengerName   string    `json:"passengerName"`
-    ProfilePic      string    `json:"profilePic"`
-    From            string    `json:"from"`
-    To              string    `json:"to"`
-    Date            string    `json:"date"`
-    Time            string    `json:"time"`
-    Passengers      int       `json:"passengers"`
-    SpecialRequests string    `json:"specialRequests,omitempty"`
-    Status          string    `json:"status"`
-    CreatedAt       time.Time `json:"createdAt"`
-    UpdatedAt       time.Time `json:"updatedAt"`
-} 
\ No newline at end of file
+	ID              string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RideID          string     `json:"rideId"`
+	PassengerID     string     `json:"passengerId"`
+	From            string     `json:"from"`
+	To              string     `json:"to"`
+	Date            string     `json:"date"`
+	Time            string     `json:"time"`
+	Passengers      int        `json:"passengers"` // seats booked
+	SpecialRequests string     `json:"specialRequests,omitempty"`
+	Status          string     `json:"status"` // confirmed, needs_reconfirmation, cancelled, completed, no_show
+	ReconfirmBy     *time.Time `json:"reconfirmBy,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	// Price snapshot taken at booking time: Total is UnitPrice times
+	// Passengers plus Fees, all in Currency
+	UnitPrice decimal.Decimal `json:"unitPrice" gorm:"type:numeric(10,2);not null;default:0"`
+	Fees      decimal.Decimal `json:"fees" gorm:"type:numeric(10,2);not null;default:0"`
+	Total     decimal.Decimal `json:"total" gorm:"type:numeric(10,2);not null;default:0"`
+	Currency  string          `json:"currency" gorm:"type:char(3);default:USD"`
+
+	// Set when the booking is cancelled or the passenger does not show up
+	CancelledAt        *time.Time       `json:"cancelledAt,omitempty"`
+	CancellationReason string           `json:"cancellationReason,omitempty"`
+	RefundAmount       *decimal.Decimal `json:"refundAmount,omitempty" gorm:"type:numeric(10,2)"`
+
+	// Loaded with Preload("Passenger")
+	Passenger *UserSummary `json:"passenger,omitempty" gorm:"foreignKey:PassengerID;-:migration"`
+}
+
+// HoldsSeats reports whether the booking still occupies seats on its ride.
+func (b *Booking) HoldsSeats() bool {
+	return b.Status == BookingConfirmed || b.Status == BookingNeedsReconfirmation
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Ledger accounts. Drivers each have their own payable account named
+// DriverAccount(id).
+const (
+	AccountPlatformCash    = "platform:cash"    // money held from passengers
+	AccountPlatformRevenue = "platform:revenue" // platform fees earned
+	driverAccountPrefix    = "driver:"
+)
+
+// DriverAccount names the account holding what the platform owes a driver.
+func DriverAccount(driverID string) string {
+	return driverAccountPrefix + driverID
+}
+
+// Ledger transaction kinds
+const (
+	LedgerCapture = "capture"
+	LedgerRefund  = "refund"
+	LedgerPayout  = "payout"
+)
+
+// LedgerTransaction groups entries that move money between accounts. Its
+// entries always sum to zero.
+type LedgerTransaction struct {
+	ID            string        `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Kind          string        `json:"kind" gorm:"index"`
+	RideID        string        `json:"rideId,omitempty" gorm:"index"`
+	BookingID     string        `json:"bookingId,omitempty"`
+	PayoutBatchID string        `json:"payoutBatchId,omitempty" gorm:"index"`
+	Description   string        `json:"description"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	Entries       []LedgerEntry `json:"entries" gorm:"foreignKey:TransactionID"`
+}
+
+// LedgerEntry is one side of a transaction. Debits are positive and
+// credits negative, so a driver's balance owed is the negated sum of their
+// account's entries.
+type LedgerEntry struct {
+	ID            string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TransactionID string          `json:"transactionId" gorm:"type:uuid;index"`
+	Account       string          `json:"account" gorm:"index"`
+	Amount        decimal.Decimal `json:"amount" gorm:"type:numeric(12,2)"`
+	Currency      string          `json:"currency" gorm:"type:char(3)"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// PayoutBatch is a set of driver payouts exported together.
+type PayoutBatch struct {
+	ID         string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Payouts    int             `json:"payouts"`
+	Total      decimal.Decimal `json:"total" gorm:"type:numeric(12,2)"`
+	Currency   string          `json:"currency" gorm:"type:char(3)"`
+	ExportPath string          `json:"exportPath"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
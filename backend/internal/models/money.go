@@ -0,0 +1,4 @@
+package models
+
+// DefaultCurrency is used for rows created before currencies were stored.
+const DefaultCurrency = "USD"
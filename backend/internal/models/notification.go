@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID        string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string    `json:"userId" gorm:"index"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	RideID    string    `json:"rideId,omitempty"`
+	BookingID string    `json:"bookingId,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Notification types
+const (
+	NotificationRideChanged      = "ride_changed"
+	NotificationBookingCancelled = "booking_cancelled"
+	NotificationBookingAccepted  = "booking_reconfirmed"
+	NotificationRideCancelled    = "ride_cancelled"
+	NotificationReviewReceived   = "review_received"
+	NotificationRideMatch        = "ride_match"
+)
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Payment statuses
+const (
+	PaymentAuthorized        = "authorized"
+	PaymentCaptured          = "captured"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentVoided            = "voided"
+	PaymentFailed            = "failed"
+)
+
+// Payment tracks the charge for a booking at the payment gateway. Amount is
+// authorized when the booking is confirmed and captured, possibly in part,
+// when the trip completes or the booking is cancelled.
+type Payment struct {
+	ID             string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	BookingID      string          `json:"bookingId" gorm:"uniqueIndex"`
+	Provider       string          `json:"provider"`
+	ProviderRef    string          `json:"providerRef" gorm:"uniqueIndex"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:numeric(10,2)"`
+	CapturedAmount decimal.Decimal `json:"capturedAmount" gorm:"type:numeric(10,2);default:0"`
+	RefundedAmount decimal.Decimal `json:"refundedAmount" gorm:"type:numeric(10,2);default:0"`
+	Currency       string          `json:"currency" gorm:"type:char(3)"`
+	Status         string          `json:"status"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+// PaymentEvent records a processed gateway webhook so redeliveries are
+// ignored.
+type PaymentEvent struct {
+	ID          string    `json:"id" gorm:"primaryKey"` // provider:event id
+	Type        string    `json:"type"`
+	ProviderRef string    `json:"providerRef" gorm:"index"`
+	ProcessedAt time.Time `json:"processedAt"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Review roles, naming the side of the booking being reviewed
+const (
+	ReviewOfDriver    = "driver"
+	ReviewOfPassenger = "passenger"
+)
+
+// Review is one side's rating of the other for a completed booking. Each
+// side can review a booking once.
+type Review struct {
+	ID         string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	BookingID  string    `json:"bookingId" gorm:"type:uuid;uniqueIndex:idx_reviews_booking_reviewer"`
+	RideID     string    `json:"rideId" gorm:"type:uuid"`
+	ReviewerID string    `json:"reviewerId" gorm:"uniqueIndex:idx_reviews_booking_reviewer"`
+	RevieweeID string    `json:"revieweeId" gorm:"index"`
+	Role       string    `json:"role"` // driver or passenger
+	Rating     int       `json:"rating"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -1,23 +1,128 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	rideDateLayout  = "2006-01-02"
+	rideClockLayout = "15:04"
+)
+
+// Booking modes: instant rides are booked directly, approval rides take
+// ride requests the driver accepts or rejects
+const (
+	BookingModeInstant  = "instant"
+	BookingModeApproval = "approval"
+)
+
+// Ride statuses
+const (
+	RideAvailable = "available"
+	RideFull      = "full"
+	RideCompleted = "completed"
+	RideCancelled = "cancelled"
 )
 
 type Ride struct {
-	ID          string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	From        string    `json:"from"`
-	To          string    `json:"to"`
-	Date        string    `json:"date" gorm:"type:date"`
-	Time        string    `json:"time" gorm:"type:time without time zone"`
-	Price       float64   `json:"price"`
-	Seats       int       `json:"seats"`
-	Driver      string    `json:"driver"`
-	DriverName  string    `json:"driverName"`
-	Description string    `json:"description,omitempty"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Date        string          `json:"date" gorm:"type:date"`
+	Time        string          `json:"time" gorm:"type:time without time zone"`
+	Price       decimal.Decimal `json:"price" gorm:"type:numeric(10,2)"` // per seat, in Currency
+	Currency    string          `json:"currency" gorm:"type:char(3);default:USD"`
+	Seats       int             `json:"seats"`
+	Driver      string          `json:"driver"`
+	DriverName  string          `json:"driverName"`
+	Description string          `json:"description,omitempty"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Route estimate, nil when it could not be computed
+	DistanceMeters  *int `json:"distanceMeters,omitempty"`
+	DurationSeconds *int `json:"durationSeconds,omitempty"`
+
+	// Driver's rules, stored as columns of the ride
+	Preferences RidePreferences `json:"preferences" gorm:"embedded"`
+	BookingMode string          `json:"bookingMode" gorm:"default:instant"` // instant or approval
+
+	// Vehicle the ride is offered in; nil for rides listed before vehicles
+	// were required. Vehicle is loaded with Preload("Vehicle").
+	VehicleID *string  `json:"vehicleId,omitempty" gorm:"type:uuid;index"`
+	Vehicle   *Vehicle `json:"vehicle,omitempty" gorm:"-:migration"`
+
+	// Schedule the ride was created from and the date it was created for,
+	// nil for one-off rides. The date is kept when the ride is moved so the
+	// schedule never creates that occurrence again.
+	ScheduleID     *string `json:"scheduleId,omitempty" gorm:"type:uuid;uniqueIndex:idx_rides_schedule_occurrence"`
+	OccurrenceDate *string `json:"-" gorm:"type:date;uniqueIndex:idx_rides_schedule_occurrence"`
+
+	// Set when the driver edits a scheduled ride on its own; edits to the
+	// whole series then leave it alone
+	Customized bool `json:"customized,omitempty" gorm:"not null;default:false"`
+
+	// Driver's review average, only loaded by searches that join users
+	DriverRating      *float64 `json:"driverRating,omitempty" gorm:"->;-:migration"`
+	DriverRatingCount *int     `json:"driverRatingCount,omitempty" gorm:"->;-:migration"`
+}
+
+// MarshalJSON adds the estimated arrival to the stored fields.
+func (r Ride) MarshalJSON() ([]byte, error) {
+	type ride Ride
+	return json.Marshal(struct {
+		ride
+		EstimatedArrival *time.Time `json:"estimatedArrival,omitempty"`
+	}{ride(r), r.EstimatedArrival()})
+}
+
+// DepartureDate returns the ride date as YYYY-MM-DD. Postgres date columns
+// scanned into a string come back as RFC 3339 timestamps.
+func (r *Ride) DepartureDate() string {
+	if len(r.Date) > len(rideDateLayout) {
+		return r.Date[:len(rideDateLayout)]
+	}
+	return r.Date
+}
+
+// ScheduledDate returns the date the ride's schedule created it for as
+// YYYY-MM-DD, or "" for a one-off ride.
+func (r *Ride) ScheduledDate() string {
+	if r.OccurrenceDate == nil {
+		return ""
+	}
+	return trimLayout(*r.OccurrenceDate, rideDateLayout)
+}
+
+// DepartureClock returns the ride time as HH:MM, dropping any seconds.
+func (r *Ride) DepartureClock() string {
+	if len(r.Time) > len(rideClockLayout) {
+		return r.Time[:len(rideClockLayout)]
+	}
+	return r.Time
+}
+
+// Departure combines the ride date and time in the server's local zone.
+func (r *Ride) Departure() (time.Time, error) {
+	return time.ParseInLocation(rideDateLayout+" "+rideClockLayout, r.DepartureDate()+" "+r.DepartureClock(), time.Local)
+}
+
+// EstimatedArrival is the departure plus the estimated duration, or nil
+// when either is unknown.
+func (r *Ride) EstimatedArrival() *time.Time {
+	if r.DurationSeconds == nil {
+		return nil
+	}
+	departure, err := r.Departure()
+	if err != nil {
+		return nil
+	}
+	arrival := departure.Add(time.Duration(*r.DurationSeconds) * time.Second)
+	return &arrival
 }
 
 type Location struct {
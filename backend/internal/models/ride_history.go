@@ -1,21 +1,26 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type RideHistory struct {
-	ID          string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	RideID      string    `json:"rideId"`
-	From        string    `json:"from"`
-	To          string    `json:"to"`
-	Date        string    `json:"date"`
-	Time        string    `json:"time"`
-	Price       float64   `json:"price"`
-	Seats       int       `json:"seats"`
-	Driver      string    `json:"driver"`
-	DriverName  string    `json:"driverName"`
-	Description string    `json:"description,omitempty"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	CompletedAt time.Time `json:"completedAt"`
+	ID          string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RideID      string          `json:"rideId"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Date        string          `json:"date"`
+	Time        string          `json:"time"`
+	Price       decimal.Decimal `json:"price" gorm:"type:numeric(10,2)"` // per seat, in Currency
+	Currency    string          `json:"currency" gorm:"type:char(3);default:USD"`
+	Seats       int             `json:"seats"`
+	Driver      string          `json:"driver"`
+	DriverName  string          `json:"driverName"`
+	Description string          `json:"description,omitempty"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+	CompletedAt time.Time       `json:"completedAt"`
 }
@@ -0,0 +1,73 @@
+package models
+
+// Luggage sizes a ride accepts, smallest first
+const (
+	LuggageNone   = "none"
+	LuggageSmall  = "small"
+	LuggageMedium = "medium"
+	LuggageLarge  = "large"
+)
+
+// LuggageSizes lists the luggage sizes in increasing order.
+var LuggageSizes = []string{LuggageNone, LuggageSmall, LuggageMedium, LuggageLarge}
+
+// LuggageAtLeast returns the sizes that fit luggage of the given size.
+func LuggageAtLeast(size string) []string {
+	for i, s := range LuggageSizes {
+		if s == size {
+			return LuggageSizes[i:]
+		}
+	}
+	return nil
+}
+
+// RidePreferences are the driver's rules for a ride.
+type RidePreferences struct {
+	SmokingAllowed bool   `json:"smokingAllowed"`
+	PetsAllowed    bool   `json:"petsAllowed"`
+	Luggage        string `json:"luggage" gorm:"default:medium"` // largest luggage accepted
+	Music          bool   `json:"music"`
+	WomenOnly      bool   `json:"womenOnly"`
+	MaxTwoInBack   bool   `json:"maxTwoInBack"`
+}
+
+// RideFilters narrow a ride search by preference. Nil fields and an empty
+// Luggage match any ride.
+type RideFilters struct {
+	SmokingAllowed *bool  `json:"smokingAllowed,omitempty"`
+	PetsAllowed    *bool  `json:"petsAllowed,omitempty"`
+	Luggage        string `json:"luggage,omitempty"` // smallest luggage size the ride must accept
+	Music          *bool  `json:"music,omitempty"`
+	WomenOnly      *bool  `json:"womenOnly,omitempty"`
+	InstantBooking *bool  `json:"instantBooking,omitempty"` // matches the ride's booking mode
+	MaxTwoInBack   *bool  `json:"maxTwoInBack,omitempty"`
+}
+
+// Matches reports whether a ride with prefs and bookingMode passes the
+// filters. It mirrors the search query's filtering for a single ride.
+func (f RideFilters) Matches(prefs RidePreferences, bookingMode string) bool {
+	for _, flag := range []struct {
+		want *bool
+		got  bool
+	}{
+		{f.SmokingAllowed, prefs.SmokingAllowed},
+		{f.PetsAllowed, prefs.PetsAllowed},
+		{f.Music, prefs.Music},
+		{f.WomenOnly, prefs.WomenOnly},
+		{f.MaxTwoInBack, prefs.MaxTwoInBack},
+		{f.InstantBooking, bookingMode == BookingModeInstant},
+	} {
+		if flag.want != nil && *flag.want != flag.got {
+			return false
+		}
+	}
+	if f.Luggage != "" {
+		for _, size := range LuggageAtLeast(f.Luggage) {
+			if size == prefs.Luggage {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestRideFiltersMatches(t *testing.T) {
+	yes, no := true, false
+	prefs := RidePreferences{PetsAllowed: true, Luggage: LuggageMedium, Music: false}
+
+	tests := []struct {
+		name    string
+		filters RideFilters
+		mode    string
+		want    bool
+	}{
+		{"no filters", RideFilters{}, BookingModeApproval, true},
+		{"flag matches", RideFilters{PetsAllowed: &yes, Music: &no}, BookingModeInstant, true},
+		{"flag differs", RideFilters{PetsAllowed: &no}, BookingModeInstant, false},
+		{"smaller luggage fits", RideFilters{Luggage: LuggageSmall}, BookingModeInstant, true},
+		{"same luggage fits", RideFilters{Luggage: LuggageMedium}, BookingModeInstant, true},
+		{"larger luggage does not fit", RideFilters{Luggage: LuggageLarge}, BookingModeInstant, false},
+		{"instant booking wanted", RideFilters{InstantBooking: &yes}, BookingModeApproval, false},
+		{"approval wanted", RideFilters{InstantBooking: &no}, BookingModeApproval, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.Matches(prefs, tt.mode); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
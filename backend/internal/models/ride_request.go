@@ -6,8 +6,6 @@ type RideRequest struct {
 	ID              string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	RideID          string    `json:"rideId"`
 	PassengerID     string    `json:"passengerId"`
-	PassengerName   string    `json:"passengerName"`
-	ProfilePic      string    `json:"profilePic"`
 	From            string    `json:"from"`
 	To              string    `json:"to"`
 	Date            string    `json:"date"`
@@ -17,4 +15,7 @@ type RideRequest struct {
 	Status          string    `json:"status"` // pending, approved, rejected
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
+
+	// Loaded with Preload("Passenger")
+	Passenger *UserSummary `json:"passenger,omitempty" gorm:"foreignKey:PassengerID;-:migration"`
 }
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Schedule statuses
+const (
+	ScheduleActive    = "active"
+	ScheduleCancelled = "cancelled"
+)
+
+// Weekdays are the day codes a schedule recurs on, indexed by time.Weekday.
+var Weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// RideSchedule is a ride a driver offers repeatedly, e.g. every weekday at
+// 08:00. Rides are created from it ahead of time and can then be edited or
+// cancelled one by one like any other ride.
+type RideSchedule struct {
+	ID          string          `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Driver      string          `json:"driver" gorm:"index"`
+	DriverName  string          `json:"driverName"`
+	VehicleID   string          `json:"vehicleId" gorm:"type:uuid"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Time        string          `json:"time" gorm:"type:time without time zone"`
+	Price       decimal.Decimal `json:"price" gorm:"type:numeric(10,2)"` // per seat, in Currency
+	Currency    string          `json:"currency" gorm:"type:char(3);default:USD"`
+	Seats       int             `json:"seats"`
+	Description string          `json:"description,omitempty"`
+	Preferences RidePreferences `json:"preferences" gorm:"embedded"`
+	BookingMode string          `json:"bookingMode" gorm:"default:instant"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Route estimate copied to each ride, nil when it could not be computed
+	DistanceMeters  *int `json:"distanceMeters,omitempty"`
+	DurationSeconds *int `json:"durationSeconds,omitempty"`
+
+	// Recurrence: the listed weekdays from StartDate through Until (open
+	// ended when nil), except the dates in Exceptions
+	Days       pq.StringArray `json:"days" gorm:"type:text[]"`
+	StartDate  string         `json:"startDate" gorm:"type:date"`
+	Until      *string        `json:"until,omitempty" gorm:"type:date"`
+	Exceptions pq.StringArray `json:"exceptions" gorm:"type:text[]"`
+}
+
+// AfterFind trims the date and time columns, which Postgres returns as
+// timestamps, to YYYY-MM-DD and HH:MM.
+func (s *RideSchedule) AfterFind(tx *gorm.DB) error {
+	s.StartDate = trimLayout(s.StartDate, rideDateLayout)
+	if s.Until != nil {
+		until := trimLayout(*s.Until, rideDateLayout)
+		s.Until = &until
+	}
+	s.Time = trimLayout(s.Time, rideClockLayout)
+	return nil
+}
+
+// OccursOn reports whether the schedule has a ride on day.
+func (s *RideSchedule) OccursOn(day time.Time) bool {
+	date := day.Format(rideDateLayout)
+	if date < s.StartDate || (s.Until != nil && date > *s.Until) {
+		return false
+	}
+	for _, exception := range s.Exceptions {
+		if exception == date {
+			return false
+		}
+	}
+	for _, code := range s.Days {
+		if code == Weekdays[day.Weekday()] {
+			return true
+		}
+	}
+	return false
+}
+
+// RideOn builds the available ride the schedule offers on date.
+func (s *RideSchedule) RideOn(date string) Ride {
+	vehicleID, scheduleID, occurrence := s.VehicleID, s.ID, date
+	return Ride{
+		From:            s.From,
+		To:              s.To,
+		Date:            date,
+		Time:            s.Time,
+		Price:           s.Price,
+		Currency:        s.Currency,
+		Seats:           s.Seats,
+		Driver:          s.Driver,
+		DriverName:      s.DriverName,
+		Description:     s.Description,
+		Status:          RideAvailable,
+		DistanceMeters:  s.DistanceMeters,
+		DurationSeconds: s.DurationSeconds,
+		Preferences:     s.Preferences,
+		BookingMode:     s.BookingMode,
+		VehicleID:       &vehicleID,
+		ScheduleID:      &scheduleID,
+		OccurrenceDate:  &occurrence,
+	}
+}
+
+// RideChanges returns the column updates that bring an unbooked ride of the
+// schedule in line with it. The ride keeps its date.
+func (s *RideSchedule) RideChanges() map[string]interface{} {
+	return map[string]interface{}{
+		"from":             s.From,
+		"to":               s.To,
+		"time":             s.Time,
+		"price":            s.Price,
+		"seats":            s.Seats,
+		"status":           RideAvailable,
+		"description":      s.Description,
+		"booking_mode":     s.BookingMode,
+		"vehicle_id":       s.VehicleID,
+		"distance_meters":  s.DistanceMeters,
+		"duration_seconds": s.DurationSeconds,
+		"smoking_allowed":  s.Preferences.SmokingAllowed,
+		"pets_allowed":     s.Preferences.PetsAllowed,
+		"luggage":          s.Preferences.Luggage,
+		"music":            s.Preferences.Music,
+		"women_only":       s.Preferences.WomenOnly,
+		"max_two_in_back":  s.Preferences.MaxTwoInBack,
+		"updated_at":       time.Now(),
+	}
+}
+
+func trimLayout(value, layout string) string {
+	if len(value) > len(layout) {
+		return value[:len(layout)]
+	}
+	return value
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestRideScheduleOccursOn(t *testing.T) {
+	until := "2030-06-30"
+	schedule := &RideSchedule{
+		Days:       pq.StringArray{"MO", "WE", "FR"},
+		StartDate:  "2030-06-05",
+		Until:      &until,
+		Exceptions: pq.StringArray{"2030-06-14"},
+	}
+
+	tests := []struct {
+		date string
+		want bool
+	}{
+		{"2030-06-03", false}, // Monday before the start
+		{"2030-06-05", true},  // Wednesday, first day
+		{"2030-06-06", false}, // Thursday
+		{"2030-06-07", true},  // Friday
+		{"2030-06-14", false}, // Friday, skipped
+		{"2030-06-28", true},  // Friday before the end
+		{"2030-07-01", false}, // Monday after the end
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			day, err := time.Parse(rideDateLayout, tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.OccursOn(day); got != tt.want {
+				t.Errorf("OccursOn(%s) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+
+	schedule.Until = nil
+	if day, _ := time.Parse(rideDateLayout, "2031-01-03"); !schedule.OccursOn(day) {
+		t.Error("open-ended schedule does not occur on a later Friday")
+	}
+}
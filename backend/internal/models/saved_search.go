@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SavedSearch is a ride search a passenger is alerted about when a ride
+// matching it is listed or changed to match.
+type SavedSearch struct {
+	ID        string           `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string           `json:"userId" gorm:"index"`
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	DateFrom  string           `json:"dateFrom" gorm:"type:date"`
+	DateTo    string           `json:"dateTo" gorm:"type:date"`
+	Seats     int              `json:"seats"`
+	MaxPrice  *decimal.Decimal `json:"maxPrice,omitempty" gorm:"type:numeric(10,2)"`
+	Filters   RideFilters      `json:"filters" gorm:"embedded;embeddedPrefix:filter_"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// AfterFind trims the date columns, which Postgres returns as timestamps,
+// to YYYY-MM-DD.
+func (s *SavedSearch) AfterFind(tx *gorm.DB) error {
+	s.DateFrom = trimLayout(s.DateFrom, rideDateLayout)
+	s.DateTo = trimLayout(s.DateTo, rideDateLayout)
+	return nil
+}
+
+// SavedSearchAlert records that a search's owner was alerted about a ride,
+// so later edits to the ride do not alert them again.
+type SavedSearchAlert struct {
+	SavedSearchID string `gorm:"type:uuid;primaryKey"`
+	RideID        string `gorm:"type:uuid;primaryKey"`
+	CreatedAt     time.Time
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -17,6 +20,69 @@ type User struct {
 	ProfileImage *string   `json:"profile_image,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Average of the reviews this user has received, kept up to date as
+	// reviews are submitted
+	RatingAverage *float64 `json:"rating,omitempty" gorm:"type:numeric(3,2)"`
+	RatingCount   int      `json:"rating_count"`
+
+	// Editable profile. Phone is only shown to the user themselves.
+	Bio         string          `json:"bio,omitempty"`
+	Phone       string          `json:"phone,omitempty"`
+	Preferences UserPreferences `json:"preferences" gorm:"type:jsonb"`
+
+	// Set when the user uploads a profile photo, which then replaces
+	// ProfileImage. PhotoKey locates the stored files.
+	ProfileThumbnail *string `json:"profile_thumbnail,omitempty"`
+	PhotoKey         string  `json:"-"`
+}
+
+// UserPreferences are account settings chosen by the user.
+type UserPreferences struct {
+	Chattiness         string `json:"chattiness,omitempty"` // quiet, some, chatty
+	Language           string `json:"language,omitempty"`   // BCP 47 tag
+	EmailNotifications bool   `json:"emailNotifications"`
+
+	// Default ride search filters, applied by clients when searching
+	RideFilters *RideFilters `json:"rideFilters,omitempty"`
+}
+
+// Value stores the preferences as JSON.
+func (p UserPreferences) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan reads preferences stored as JSON. Users created before preferences
+// existed have none.
+func (p *UserPreferences) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*p = UserPreferences{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported preferences value %T", value)
+	}
+	return json.Unmarshal(data, p)
+}
+
+// UserSummary is the public part of a user shown next to the bookings and
+// requests they make.
+type UserSummary struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	ProfileImage     *string  `json:"profileImage,omitempty"`
+	ProfileThumbnail *string  `json:"profileThumbnail,omitempty"`
+	RatingAverage    *float64 `json:"rating,omitempty"`
+	RatingCount      int      `json:"ratingCount"`
+}
+
+func (UserSummary) TableName() string {
+	return "users"
 }
 
 type UserRepository struct {
@@ -56,6 +122,14 @@ func (r *UserRepository) GetByID(id string) (*User, error) {
 	return &user, nil
 }
 
+// Update applies column changes to a user and returns the updated row.
+func (r *UserRepository) Update(id string, changes map[string]interface{}) (*User, error) {
+	if err := r.db.Model(&User{ID: id}).Updates(changes).Error; err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
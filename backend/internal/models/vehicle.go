@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Vehicle amenities
+const (
+	AmenityAirConditioning = "air_conditioning"
+	AmenityUSBCharging     = "usb_charging"
+	AmenityWiFi            = "wifi"
+	AmenityChildSeat       = "child_seat"
+	AmenityBikeRack        = "bike_rack"
+	AmenitySkiRack         = "ski_rack"
+	AmenityWheelchair      = "wheelchair_accessible"
+)
+
+// Vehicle is a car registered by a driver. Capacity counts passenger seats,
+// not including the driver's.
+type Vehicle struct {
+	ID        string         `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OwnerID   string         `json:"ownerId" gorm:"index"`
+	Make      string         `json:"make"`
+	Model     string         `json:"model"`
+	Color     string         `json:"color"`
+	Plate     string         `json:"plate"`
+	Capacity  int            `json:"capacity"`
+	Amenities pq.StringArray `json:"amenities" gorm:"type:text[]"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ride_sharing/backend/internal/metrics"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter applies a token bucket per client. Buckets idle for longer than
+// idleTTL are dropped so the map does not grow without bound.
+type Limiter struct {
+	mu         sync.Mutex
+	clients    map[string]*client
+	rate       rate.Limit
+	burst      int
+	idleTTL    time.Duration
+	trustProxy bool
+	lastSweep  time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New allows each client perSecond requests on average with bursts of up
+// to burst. With trustProxy the client is identified by the first
+// X-Forwarded-For address instead of the connection's remote address.
+func New(perSecond float64, burst int, trustProxy bool) *Limiter {
+	return &Limiter{
+		clients:    make(map[string]*client),
+		rate:       rate.Limit(perSecond),
+		burst:      burst,
+		idleTTL:    10 * time.Minute,
+		trustProxy: trustProxy,
+		lastSweep:  time.Now(),
+	}
+}
+
+// Middleware responds 429 with Retry-After once a client exceeds its rate.
+func (l *Limiter) Middleware(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter := l.limiterFor(l.clientKey(r))
+		reservation := limiter.Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			slog.WarnContext(r.Context(), "rate limit exceeded", "limiter", name)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (l *Limiter) limiterFor(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > l.idleTTL {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > l.idleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.rate, l.burst)}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+func (l *Limiter) clientKey(r *http.Request) string {
+	if l.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockBooking locks a booking's ride and then the booking. Every path that
+// changes both takes the locks in this order, so they cannot deadlock. It
+// returns nil for an unknown booking.
+func LockBooking(tx *gorm.DB, id string) (*models.Ride, *models.Booking, error) {
+	var booking models.Booking
+	err := tx.Select("ride_id").First(&booking, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get booking: %v", err)
+	}
+
+	var ride models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ride, "id = ?", booking.RideID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get ride: %v", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, "id = ?", id).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get booking: %v", err)
+	}
+	return &ride, &booking, nil
+}
+
+// CancelBooking marks a booking cancelled by actor, refunds its payment as
+// the policy decides and, before departure, returns its seats to the ride,
+// reopening the ride if it was full. It must run inside a transaction that
+// has locked ride, as LockBooking does.
+func CancelBooking(tx *gorm.DB, payments *PaymentService, policy *CancellationPolicy, ride *models.Ride, booking *models.Booking, actor string) error {
+	if !booking.HoldsSeats() {
+		return fmt.Errorf("booking %s is already %s", booking.ID, booking.Status)
+	}
+
+	now := time.Now()
+	refund := policy.Evaluate(ride, booking, actor, now)
+
+	// A departed ride cannot take anyone else, so its seats stay taken
+	if departure, err := ride.Departure(); err != nil || departure.After(now) {
+		ride.Seats += booking.Passengers
+		if ride.Status == models.RideFull {
+			ride.Status = models.RideAvailable
+		}
+		ride.UpdatedAt = now
+		if err := tx.Model(ride).Updates(map[string]interface{}{
+			"seats":      ride.Seats,
+			"status":     ride.Status,
+			"updated_at": ride.UpdatedAt,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to release seats: %v", err)
+		}
+	}
+
+	return closeBooking(tx, payments, booking, models.BookingCancelled, refund)
+}
+
+// CancelRide cancels a ride on the driver's behalf: its bookings are
+// cancelled with a full refund and their passengers notified. It returns
+// the number of bookings cancelled and must run inside a transaction that
+// has locked ride.
+func CancelRide(tx *gorm.DB, payments *PaymentService, policy *CancellationPolicy, notifications *NotificationService, ride *models.Ride) (int, error) {
+	var bookings []models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("ride_id = ? AND status IN ?", ride.ID,
+		[]string{models.BookingConfirmed, models.BookingNeedsReconfirmation}).Find(&bookings).Error; err != nil {
+		return 0, fmt.Errorf("failed to get bookings: %v", err)
+	}
+
+	for i := range bookings {
+		booking := &bookings[i]
+		if err := CancelBooking(tx, payments, policy, ride, booking, CancelledByDriver); err != nil {
+			return 0, err
+		}
+		if err := notifications.Notify(tx, &models.Notification{
+			UserID:    booking.PassengerID,
+			Type:      models.NotificationRideCancelled,
+			Message:   fmt.Sprintf("Your ride from %s to %s was cancelled by the driver. You will be refunded in full.", ride.From, ride.To),
+			RideID:    ride.ID,
+			BookingID: booking.ID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	ride.Status = models.RideCancelled
+	ride.UpdatedAt = time.Now()
+	if err := tx.Model(&models.Ride{}).Where("id = ?", ride.ID).
+		Updates(map[string]interface{}{"status": ride.Status, "updated_at": ride.UpdatedAt}).Error; err != nil {
+		return 0, fmt.Errorf("failed to cancel ride: %v", err)
+	}
+	return len(bookings), nil
+}
+
+// MarkNoShow records that the passenger did not turn up. Their seats stay
+// taken and the payment is kept as the policy decides.
+func MarkNoShow(tx *gorm.DB, payments *PaymentService, policy *CancellationPolicy, ride *models.Ride, booking *models.Booking) error {
+	if !booking.HoldsSeats() {
+		return fmt.Errorf("booking %s is already %s", booking.ID, booking.Status)
+	}
+	refund := policy.Evaluate(ride, booking, CancelledByNoShow, time.Now())
+	return closeBooking(tx, payments, booking, models.BookingNoShow, refund)
+}
+
+// closeBooking settles the payment and records the outcome on the booking.
+func closeBooking(tx *gorm.DB, payments *PaymentService, booking *models.Booking, status string, refund RefundDecision) error {
+	if err := payments.Refund(tx, booking.ID, refund.Amount); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	booking.Status = status
+	booking.ReconfirmBy = nil
+	booking.CancelledAt = &now
+	booking.CancellationReason = refund.Reason
+	booking.RefundAmount = &refund.Amount
+	booking.UpdatedAt = now
+	if err := tx.Save(booking).Error; err != nil {
+		return fmt.Errorf("failed to cancel booking: %v", err)
+	}
+
+	return nil
+}
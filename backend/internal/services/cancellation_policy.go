@@ -0,0 +1,86 @@
+package services
+
+import (
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// Who or what ended a booking, as passed to CancellationPolicy.Evaluate.
+const (
+	CancelledByPassenger  = "passenger"
+	CancelledByDriver     = "driver"
+	CancelledByRideChange = "ride_change"
+	CancelledByNoShow     = "no_show"
+)
+
+// Refund reasons recorded on cancelled bookings.
+const (
+	RefundDriverCancelled  = "driver_cancelled"
+	RefundRideChanged      = "ride_changed"
+	RefundGracePeriod      = "grace_period"
+	RefundBeforeDeadline   = "before_deadline"
+	RefundLateCancellation = "late_cancellation"
+	RefundAfterDeparture   = "after_departure"
+	RefundNoShow           = "no_show"
+)
+
+// RefundDecision is the amount returned to the passenger and why.
+type RefundDecision struct {
+	Amount decimal.Decimal
+	Reason string
+}
+
+// CancellationPolicy decides refunds. Passengers get everything back when
+// they cancel within gracePeriod of booking or at least fullRefundBefore
+// ahead of departure, partialPercent of the seats' price (fees are kept)
+// until departure, and nothing afterwards or for a no-show. Cancellations
+// the passenger did not cause are always refunded in full.
+type CancellationPolicy struct {
+	fullRefundBefore time.Duration
+	partialPercent   decimal.Decimal
+	gracePeriod      time.Duration
+}
+
+func NewCancellationPolicy(cfg *config.Config) *CancellationPolicy {
+	return &CancellationPolicy{
+		fullRefundBefore: cfg.CancellationFullRefundBefore,
+		partialPercent:   decimal.NewFromFloat(cfg.CancellationPartialRefundPercent),
+		gracePeriod:      cfg.CancellationGracePeriod,
+	}
+}
+
+// Evaluate applies the policy to a booking cancelled by actor at now.
+func (p *CancellationPolicy) Evaluate(ride *models.Ride, booking *models.Booking, actor string, now time.Time) RefundDecision {
+	full := booking.Total
+	switch actor {
+	case CancelledByDriver:
+		return RefundDecision{Amount: full, Reason: RefundDriverCancelled}
+	case CancelledByRideChange:
+		return RefundDecision{Amount: full, Reason: RefundRideChanged}
+	case CancelledByNoShow:
+		return RefundDecision{Amount: decimal.Zero, Reason: RefundNoShow}
+	}
+
+	departure, err := ride.Departure()
+	if err != nil {
+		// Without a departure time, err on the passenger's side
+		return RefundDecision{Amount: full, Reason: RefundBeforeDeadline}
+	}
+
+	switch {
+	case !now.Before(departure):
+		return RefundDecision{Amount: decimal.Zero, Reason: RefundAfterDeparture}
+	case now.Sub(booking.CreatedAt) <= p.gracePeriod:
+		return RefundDecision{Amount: full, Reason: RefundGracePeriod}
+	case departure.Sub(now) >= p.fullRefundBefore:
+		return RefundDecision{Amount: full, Reason: RefundBeforeDeadline}
+	default:
+		seats := booking.UnitPrice.Mul(decimal.NewFromInt(int64(booking.Passengers)))
+		partial := seats.Mul(p.partialPercent).Div(decimal.NewFromInt(100)).Round(2)
+		return RefundDecision{Amount: partial, Reason: RefundLateCancellation}
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCancellationPolicyEvaluate(t *testing.T) {
+	policy := NewCancellationPolicy(&config.Config{
+		CancellationFullRefundBefore:     24 * time.Hour,
+		CancellationPartialRefundPercent: 50,
+		CancellationGracePeriod:          time.Hour,
+	})
+
+	ride := &models.Ride{Date: "2030-06-10", Time: "08:00"}
+	departure, err := ride.Departure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	longAgo := departure.Add(-7 * 24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		ride       *models.Ride
+		actor      string
+		bookedAt   time.Time
+		now        time.Time
+		wantAmount string
+		wantReason string
+	}{
+		{"driver cancelled", ride, CancelledByDriver, longAgo, departure.Add(-time.Minute), "21", RefundDriverCancelled},
+		{"ride changed", ride, CancelledByRideChange, longAgo, departure.Add(time.Hour), "21", RefundRideChanged},
+		{"no-show", ride, CancelledByNoShow, longAgo, departure.Add(time.Hour), "0", RefundNoShow},
+		{"at departure", ride, CancelledByPassenger, longAgo, departure, "0", RefundAfterDeparture},
+		{"after departure within grace", ride, CancelledByPassenger, departure.Add(-time.Minute), departure.Add(time.Minute), "0", RefundAfterDeparture},
+		{"within grace period", ride, CancelledByPassenger, departure.Add(-2 * time.Hour), departure.Add(-90 * time.Minute), "21", RefundGracePeriod},
+		{"grace period boundary", ride, CancelledByPassenger, departure.Add(-3 * time.Hour), departure.Add(-2 * time.Hour), "21", RefundGracePeriod},
+		{"deadline boundary", ride, CancelledByPassenger, longAgo, departure.Add(-24 * time.Hour), "21", RefundBeforeDeadline},
+		{"well before deadline", ride, CancelledByPassenger, longAgo, departure.Add(-48 * time.Hour), "21", RefundBeforeDeadline},
+		{"just after deadline", ride, CancelledByPassenger, longAgo, departure.Add(-24*time.Hour + time.Second), "10", RefundLateCancellation},
+		{"shortly before departure", ride, CancelledByPassenger, longAgo, departure.Add(-time.Minute), "10", RefundLateCancellation},
+		{"unknown departure", &models.Ride{Date: "soon"}, CancelledByPassenger, longAgo, departure, "21", RefundBeforeDeadline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Two seats at 10 plus a fee of 1; partial refunds exclude the fee
+			booking := &models.Booking{
+				Passengers: 2,
+				UnitPrice:  decimal.NewFromInt(10),
+				Total:      decimal.NewFromInt(21),
+				CreatedAt:  tt.bookedAt,
+			}
+			got := policy.Evaluate(tt.ride, booking, tt.actor, tt.now)
+			if !got.Amount.Equal(decimal.RequireFromString(tt.wantAmount)) || got.Reason != tt.wantReason {
+				t.Errorf("Evaluate() = %s %s, want %s %s", got.Amount, got.Reason, tt.wantAmount, tt.wantReason)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/logging"
+	"ride_sharing/backend/internal/metrics"
 	"ride_sharing/backend/internal/models"
+	"ride_sharing/backend/internal/tracing"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
@@ -17,6 +21,25 @@ type Database struct {
 	*gorm.DB
 }
 
+// migratedModels are the tables created by AutoMigrate in NewDatabase.
+var migratedModels = []interface{}{
+	&models.Ride{},
+	&models.Booking{},
+	&models.RideHistory{},
+	&models.RideRequest{},
+	&models.Notification{},
+	&models.Payment{},
+	&models.PaymentEvent{},
+	&models.LedgerTransaction{},
+	&models.LedgerEntry{},
+	&models.PayoutBatch{},
+	&models.Review{},
+	&models.Vehicle{},
+	&models.RideSchedule{},
+	&models.SavedSearch{},
+	&models.SavedSearchAlert{},
+}
+
 func NewDatabase(cfg *config.Config) (*Database, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		cfg.DBHost,
@@ -26,11 +49,20 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		cfg.DBPort,
 	)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logging.NewGormLogger(slog.Default(), 200*time.Millisecond),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register metrics plugin: %v", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %v", err)
+	}
+
 	// Enable UUID generation support
 	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "pgcrypto"`).Error; err != nil {
 		return nil, fmt.Errorf("failed to enable pgcrypto extension: %v", err)
@@ -49,18 +81,87 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to set default UUID on rides.id: %v", err)
 	}
 
+	// Bookings made before price snapshots may hold NULL prices, which
+	// would stop the columns becoming NOT NULL
+	if db.Migrator().HasColumn(&models.Booking{}, "UnitPrice") {
+		if err := backfillBookingPrices(db); err != nil {
+			return nil, err
+		}
+	}
+
 	// Auto-migrate schema
-	log.Printf("Starting database migration...")
-	err = db.AutoMigrate(&models.Ride{}, &models.Booking{}, &models.RideHistory{}, &models.RideRequest{})
+	slog.Info("starting database migration")
+	err = db.AutoMigrate(migratedModels...)
 	if err != nil {
-		log.Printf("Migration error: %v", err)
+		slog.Error("database migration failed", "error", err)
 		return nil, fmt.Errorf("failed to migrate database: %v", err)
 	}
-	log.Printf("Database migration completed successfully")
+	if err := backfillBookingPrices(db); err != nil {
+		return nil, err
+	}
+	slog.Info("database migration completed")
 
 	return &Database{db}, nil
 }
 
+// backfillBookingPrices prices bookings made before totals were snapshotted
+// at the ride's current per-seat price, without fees. Bookings whose ride is
+// gone are zeroed.
+func backfillBookingPrices(db *gorm.DB) error {
+	if err := db.Exec(`
+		UPDATE bookings
+		SET unit_price = rides.price,
+			total = rides.price * bookings.passengers,
+			fees = COALESCE(bookings.fees, 0)
+		FROM rides
+		WHERE rides.id::text = bookings.ride_id
+			AND (bookings.unit_price IS NULL OR bookings.unit_price = 0)
+			AND rides.price IS NOT NULL
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill booking prices: %v", err)
+	}
+	if err := db.Exec(`
+		UPDATE bookings
+		SET unit_price = COALESCE(unit_price, 0),
+			fees = COALESCE(fees, 0),
+			total = COALESCE(total, 0)
+		WHERE unit_price IS NULL OR fees IS NULL OR total IS NULL
+	`).Error; err != nil {
+		return fmt.Errorf("failed to backfill booking prices: %v", err)
+	}
+	return nil
+}
+
+// Ready reports whether the database is reachable and every migrated table,
+// including users, exists.
+func (db *Database) Ready(ctx context.Context) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database handle: %v", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("database unreachable: %v", err)
+	}
+
+	migrator := db.WithContext(ctx).Migrator()
+	tables := append([]interface{}{&models.User{}}, migratedModels...)
+	for _, model := range tables {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table for %T has not been migrated", model)
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying connection pool.
+func (db *Database) Close() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 func (db *Database) CreateRide(ride *models.Ride) error {
 	// Generate a new UUID for the ride
 	ride.ID = uuid.New().String()
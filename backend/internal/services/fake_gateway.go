@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of a fake webhook body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeGateway is an in-memory PaymentGateway for development and tests. It
+// enforces the same state transitions as a real provider. Authorizations
+// above declineAbove, when positive, are declined.
+type FakeGateway struct {
+	mu            sync.Mutex
+	secret        []byte
+	declineAbove  decimal.Decimal
+	payments      map[string]*fakePayment
+	byIdempotency map[string]string
+}
+
+type fakePayment struct {
+	authorized decimal.Decimal
+	captured   decimal.Decimal
+	refunded   decimal.Decimal
+	voided     bool
+}
+
+func NewFakeGateway(webhookSecret string, declineAbove decimal.Decimal) *FakeGateway {
+	return &FakeGateway{
+		secret:        []byte(webhookSecret),
+		declineAbove:  declineAbove,
+		payments:      make(map[string]*fakePayment),
+		byIdempotency: make(map[string]string),
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakeGateway) Authorize(ctx context.Context, idempotencyKey string, amount decimal.Decimal, currency string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if ref, ok := g.byIdempotency[idempotencyKey]; ok {
+		return ref, nil
+	}
+	if g.declineAbove.IsPositive() && amount.GreaterThan(g.declineAbove) {
+		return "", ErrPaymentDeclined
+	}
+
+	ref := "fake_" + uuid.NewString()
+	g.payments[ref] = &fakePayment{authorized: amount}
+	g.byIdempotency[idempotencyKey] = ref
+	return ref, nil
+}
+
+func (g *FakeGateway) Capture(ctx context.Context, reference string, amount decimal.Decimal) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[reference]
+	if !ok {
+		return fmt.Errorf("unknown payment %s", reference)
+	}
+	if !p.voided && p.captured.IsPositive() && p.captured.Equal(amount) {
+		return ErrAlreadyCaptured
+	}
+	if p.voided || p.captured.IsPositive() {
+		return fmt.Errorf("payment %s cannot be captured", reference)
+	}
+	if amount.GreaterThan(p.authorized) {
+		return fmt.Errorf("capture of %s exceeds authorized %s", amount, p.authorized)
+	}
+	p.captured = amount
+	return nil
+}
+
+func (g *FakeGateway) Void(ctx context.Context, reference string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[reference]
+	if !ok {
+		return fmt.Errorf("unknown payment %s", reference)
+	}
+	if p.captured.IsPositive() {
+		return fmt.Errorf("payment %s is already captured", reference)
+	}
+	p.voided = true
+	return nil
+}
+
+func (g *FakeGateway) Refund(ctx context.Context, reference string, amount decimal.Decimal) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, ok := g.payments[reference]
+	if !ok {
+		return fmt.Errorf("unknown payment %s", reference)
+	}
+	if p.refunded.Add(amount).GreaterThan(p.captured) {
+		return fmt.Errorf("refund of %s exceeds captured %s", amount, p.captured.Sub(p.refunded))
+	}
+	p.refunded = p.refunded.Add(amount)
+	return nil
+}
+
+// ParseWebhook accepts a JSON WebhookEvent signed with the webhook secret.
+func (g *FakeGateway) ParseWebhook(r *http.Request) (*WebhookEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(body)
+	signature, err := hex.DecodeString(r.Header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidWebhook
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Reference == "" {
+		return nil, ErrInvalidWebhook
+	}
+	return &event, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// maxGazetteerResults caps autocomplete and geocode answers, as Google does.
+const maxGazetteerResults = 5
+
+// GazetteerProvider implements PlacesProvider from a local JSON file so the
+// API can run without a Google key or network access. The file holds an
+// array of entries:
+//
+//	[{"id": "us-austin", "name": "Austin", "region": "TX", "country": "us", "lat": 30.27, "lng": -97.74}]
+type GazetteerProvider struct {
+	entries []gazetteerEntry
+	byID    map[string]gazetteerEntry
+}
+
+type gazetteerEntry struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// NewGazetteerProvider loads path, keeping only entries in country when it
+// is set.
+func NewGazetteerProvider(path, country string) (*GazetteerProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gazetteer %s: %v", path, err)
+	}
+	var all []gazetteerEntry
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse gazetteer %s: %v", path, err)
+	}
+
+	g := &GazetteerProvider{byID: make(map[string]gazetteerEntry)}
+	for _, e := range all {
+		if country != "" && !strings.EqualFold(e.Country, country) {
+			continue
+		}
+		g.entries = append(g.entries, e)
+		g.byID[e.ID] = e
+	}
+	return g, nil
+}
+
+func (e gazetteerEntry) address() string {
+	parts := []string{e.Name}
+	if e.Region != "" {
+		parts = append(parts, e.Region)
+	}
+	if e.Country != "" {
+		parts = append(parts, strings.ToUpper(e.Country))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (e gazetteerEntry) toPlace() Place {
+	return Place{
+		PlaceID:          e.ID,
+		Name:             e.Name,
+		FormattedAddress: e.address(),
+		Lat:              e.Lat,
+		Lng:              e.Lng,
+	}
+}
+
+// search returns entries whose name starts with query first, then those
+// whose address contains it.
+func (g *GazetteerProvider) search(query string) []gazetteerEntry {
+	query = normalizeInput(query)
+	if query == "" {
+		return nil
+	}
+
+	var prefix, contains []gazetteerEntry
+	for _, e := range g.entries {
+		switch {
+		case strings.HasPrefix(strings.ToLower(e.Name), query):
+			prefix = append(prefix, e)
+		case strings.Contains(strings.ToLower(e.address()), query):
+			contains = append(contains, e)
+		}
+	}
+	matches := append(prefix, contains...)
+	if len(matches) > maxGazetteerResults {
+		matches = matches[:maxGazetteerResults]
+	}
+	return matches
+}
+
+func (g *GazetteerProvider) Autocomplete(ctx context.Context, input, sessionToken string) ([]Prediction, error) {
+	matches := g.search(input)
+	predictions := make([]Prediction, 0, len(matches))
+	for _, e := range matches {
+		predictions = append(predictions, Prediction{
+			PlaceID:       e.ID,
+			Description:   e.address(),
+			MainText:      e.Name,
+			SecondaryText: strings.TrimPrefix(strings.TrimPrefix(e.address(), e.Name), ", "),
+		})
+	}
+	return predictions, nil
+}
+
+func (g *GazetteerProvider) PlaceDetails(ctx context.Context, placeID, sessionToken string) (*Place, error) {
+	e, ok := g.byID[placeID]
+	if !ok {
+		return nil, ErrPlaceNotFound
+	}
+	place := e.toPlace()
+	return &place, nil
+}
+
+func (g *GazetteerProvider) Geocode(ctx context.Context, address string) ([]Place, error) {
+	matches := g.search(address)
+	places := make([]Place, 0, len(matches))
+	for _, e := range matches {
+		places = append(places, e.toPlace())
+	}
+	return places, nil
+}
+
+func (g *GazetteerProvider) ReverseGeocode(ctx context.Context, lat, lng float64) ([]Place, error) {
+	if len(g.entries) == 0 {
+		return []Place{}, nil
+	}
+	nearest, best := g.entries[0], math.Inf(1)
+	for _, e := range g.entries {
+		if d := haversineKm(lat, lng, e.Lat, e.Lng); d < best {
+			nearest, best = e, d
+		}
+	}
+	return []Place{nearest.toPlace()}, nil
+}
+
+// haversineKm returns the great-circle distance between two coordinates.
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
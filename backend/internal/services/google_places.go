@@ -1,38 +1,229 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/metrics"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-type GooglePlacesService struct {
-	APIKey string
+const googleMapsBaseURL = "https://maps.googleapis.com/maps/api"
+
+// GooglePlacesProvider implements PlacesProvider with the Google Places and
+// Geocoding APIs.
+type GooglePlacesProvider struct {
+	APIKey  string
+	country string
+	client  *http.Client
+}
+
+func NewGooglePlacesProvider(cfg *config.Config) *GooglePlacesProvider {
+	return &GooglePlacesProvider{
+		APIKey:  cfg.GoogleMapsAPIKey,
+		country: cfg.PlacesCountry,
+		client:  newGoogleMapsClient(cfg.GoogleMapsAPIKey, cfg.PlacesTimeout),
+	}
+}
+
+// newGoogleMapsClient returns a traced client that signs requests with the
+// API key. The key is added below the tracing transport, so it is never
+// recorded on spans or in client errors.
+func newGoogleMapsClient(apiKey string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: otelhttp.NewTransport(&googleKeyTransport{key: apiKey, base: http.DefaultTransport}),
+		Timeout:   timeout,
+	}
+}
+
+// googleKeyTransport adds the key query parameter to each request.
+type googleKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *googleKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("key", t.key)
+	req.URL.RawQuery = query.Encode()
+	return t.base.RoundTrip(req)
+}
+
+type googlePrediction struct {
+	PlaceID              string `json:"place_id"`
+	Description          string `json:"description"`
+	StructuredFormatting struct {
+		MainText      string `json:"main_text"`
+		SecondaryText string `json:"secondary_text"`
+	} `json:"structured_formatting"`
+}
+
+type googlePlace struct {
+	PlaceID          string `json:"place_id"`
+	Name             string `json:"name"`
+	FormattedAddress string `json:"formatted_address"`
+	Geometry         struct {
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+	} `json:"geometry"`
 }
 
-func NewGooglePlacesService(apiKey string) *GooglePlacesService {
-	return &GooglePlacesService{APIKey: apiKey}
+func (p googlePlace) toPlace() Place {
+	return Place{
+		PlaceID:          p.PlaceID,
+		Name:             p.Name,
+		FormattedAddress: p.FormattedAddress,
+		Lat:              p.Geometry.Location.Lat,
+		Lng:              p.Geometry.Location.Lng,
+	}
 }
 
-func (s *GooglePlacesService) Autocomplete(input string) (map[string]interface{}, error) {
-	endpoint := "https://maps.googleapis.com/maps/api/place/autocomplete/json"
+func (g *GooglePlacesProvider) Autocomplete(ctx context.Context, input, sessionToken string) ([]Prediction, error) {
 	params := url.Values{}
 	params.Set("input", input)
-	params.Set("key", s.APIKey)
 	params.Set("types", "geocode")
-	params.Set("components", "country:us")
+	if g.country != "" {
+		params.Set("components", "country:"+g.country)
+	}
+	if sessionToken != "" {
+		params.Set("sessiontoken", sessionToken)
+	}
 
-	url := fmt.Sprintf("%s?%s", endpoint, params.Encode())
-	resp, err := http.Get(url)
-	if err != nil {
+	var resp struct {
+		Status      string             `json:"status"`
+		Predictions []googlePrediction `json:"predictions"`
+	}
+	if err := g.get(ctx, "autocomplete", "/place/autocomplete/json", params, &resp); err != nil {
+		return nil, err
+	}
+	if err := checkGoogleStatus("autocomplete", resp.Status); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, 0, len(resp.Predictions))
+	for _, p := range resp.Predictions {
+		predictions = append(predictions, Prediction{
+			PlaceID:       p.PlaceID,
+			Description:   p.Description,
+			MainText:      p.StructuredFormatting.MainText,
+			SecondaryText: p.StructuredFormatting.SecondaryText,
+		})
+	}
+	return predictions, nil
+}
+
+func (g *GooglePlacesProvider) PlaceDetails(ctx context.Context, placeID, sessionToken string) (*Place, error) {
+	params := url.Values{}
+	params.Set("place_id", placeID)
+	params.Set("fields", "place_id,name,formatted_address,geometry/location")
+	if sessionToken != "" {
+		params.Set("sessiontoken", sessionToken)
+	}
+
+	var resp struct {
+		Status string      `json:"status"`
+		Result googlePlace `json:"result"`
+	}
+	if err := g.get(ctx, "details", "/place/details/json", params, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status == "NOT_FOUND" || resp.Status == "ZERO_RESULTS" || resp.Status == "INVALID_REQUEST" {
+		return nil, ErrPlaceNotFound
+	}
+	if err := checkGoogleStatus("details", resp.Status); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	place := resp.Result.toPlace()
+	return &place, nil
+}
+
+func (g *GooglePlacesProvider) Geocode(ctx context.Context, address string) ([]Place, error) {
+	params := url.Values{}
+	params.Set("address", address)
+	if g.country != "" {
+		params.Set("components", "country:"+g.country)
+	}
+	return g.geocode(ctx, "geocode", params)
+}
+
+func (g *GooglePlacesProvider) ReverseGeocode(ctx context.Context, lat, lng float64) ([]Place, error) {
+	params := url.Values{}
+	params.Set("latlng", strconv.FormatFloat(lat, 'f', -1, 64)+","+strconv.FormatFloat(lng, 'f', -1, 64))
+	return g.geocode(ctx, "reverse_geocode", params)
+}
+
+func (g *GooglePlacesProvider) geocode(ctx context.Context, operation string, params url.Values) ([]Place, error) {
+	var resp struct {
+		Status  string        `json:"status"`
+		Results []googlePlace `json:"results"`
+	}
+	if err := g.get(ctx, operation, "/geocode/json", params, &resp); err != nil {
+		return nil, err
+	}
+	if err := checkGoogleStatus(operation, resp.Status); err != nil {
 		return nil, err
 	}
-	return result, nil
+
+	places := make([]Place, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		places = append(places, r.toPlace())
+	}
+	return places, nil
+}
+
+// get calls a Google Maps endpoint and decodes the JSON body into out.
+func (g *GooglePlacesProvider) get(ctx context.Context, operation, path string, params url.Values, out interface{}) error {
+	url := fmt.Sprintf("%s%s?%s", googleMapsBaseURL, path, params.Encode())
+
+	metrics.PlacesAPICalls.WithLabelValues(operation).Inc()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		metrics.PlacesAPIFailures.WithLabelValues(operation).Inc()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		metrics.PlacesAPIFailures.WithLabelValues(operation).Inc()
+		return fmt.Errorf("places API responded with HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		metrics.PlacesAPIFailures.WithLabelValues(operation).Inc()
+		return fmt.Errorf("failed to decode places response: %v", err)
+	}
+	return nil
+}
+
+// checkGoogleStatus maps a Google status to an error. ZERO_RESULTS is
+// not an error: callers return an empty list.
+func checkGoogleStatus(operation, status string) error {
+	switch status {
+	case "OK", "ZERO_RESULTS":
+		return nil
+	}
+
+	metrics.PlacesAPIFailures.WithLabelValues(operation).Inc()
+	switch status {
+	case "OVER_QUERY_LIMIT", "OVER_DAILY_LIMIT":
+		return ErrPlacesQuotaExceeded
+	case "REQUEST_DENIED":
+		return ErrPlacesDenied
+	default:
+		return fmt.Errorf("places API returned status %s", status)
+	}
 }
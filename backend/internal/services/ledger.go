@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// LedgerService records driver earnings as double-entry transactions.
+// Captured payments move money from platform cash to the driver, less the
+// platform fee; refunds of captured money reverse that in proportion; and
+// payouts settle the driver's balance.
+type LedgerService struct {
+	db         *gorm.DB
+	feePercent decimal.Decimal
+}
+
+func NewLedgerService(db *gorm.DB, platformFeePercent float64) *LedgerService {
+	return &LedgerService{db: db, feePercent: decimal.NewFromFloat(platformFeePercent)}
+}
+
+// RecordCapture credits the booking's driver with amount less the platform
+// fee. It must run in the transaction that captured the payment.
+func (s *LedgerService) RecordCapture(tx *gorm.DB, payment *models.Payment, amount decimal.Decimal) error {
+	return s.recordBookingMovement(tx, payment, models.LedgerCapture, amount)
+}
+
+// RecordRefund debits the booking's driver and the platform fee for a
+// refund of captured money.
+func (s *LedgerService) RecordRefund(tx *gorm.DB, payment *models.Payment, amount decimal.Decimal) error {
+	return s.recordBookingMovement(tx, payment, models.LedgerRefund, amount.Neg())
+}
+
+// recordBookingMovement splits a signed cash amount between the driver and
+// the platform: positive for money collected, negative for money returned.
+func (s *LedgerService) recordBookingMovement(tx *gorm.DB, payment *models.Payment, kind string, amount decimal.Decimal) error {
+	if amount.IsZero() {
+		return nil
+	}
+
+	var booking models.Booking
+	if err := tx.First(&booking, "id = ?", payment.BookingID).Error; err != nil {
+		return fmt.Errorf("failed to get booking: %v", err)
+	}
+	var ride models.Ride
+	if err := tx.First(&ride, "id = ?", booking.RideID).Error; err != nil {
+		return fmt.Errorf("failed to get ride: %v", err)
+	}
+
+	return s.post(tx, &models.LedgerTransaction{
+		Kind:        kind,
+		RideID:      ride.ID,
+		BookingID:   booking.ID,
+		Description: fmt.Sprintf("%s for booking %s", kind, booking.ID),
+		Entries:     s.splitEntries(amount, ride.Driver, payment.Currency),
+	})
+}
+
+// splitEntries books a signed cash amount to platform cash against the
+// driver and the platform fee.
+func (s *LedgerService) splitEntries(amount decimal.Decimal, driverID, currency string) []models.LedgerEntry {
+	fee := amount.Mul(s.feePercent).Div(decimal.NewFromInt(100)).Round(2)
+	return []models.LedgerEntry{
+		{Account: models.AccountPlatformCash, Amount: amount, Currency: currency},
+		{Account: models.DriverAccount(driverID), Amount: amount.Sub(fee).Neg(), Currency: currency},
+		{Account: models.AccountPlatformRevenue, Amount: fee.Neg(), Currency: currency},
+	}
+}
+
+// post stores a balanced transaction.
+func (s *LedgerService) post(tx *gorm.DB, txn *models.LedgerTransaction) error {
+	sum := decimal.Zero
+	for _, entry := range txn.Entries {
+		sum = sum.Add(entry.Amount)
+	}
+	if !sum.IsZero() {
+		return fmt.Errorf("unbalanced ledger transaction: entries sum to %s", sum)
+	}
+
+	now := time.Now()
+	txn.CreatedAt = now
+	for i := range txn.Entries {
+		txn.Entries[i].CreatedAt = now
+	}
+	if err := tx.Create(txn).Error; err != nil {
+		return fmt.Errorf("failed to record ledger transaction: %v", err)
+	}
+	return nil
+}
+
+// RideEarnings is a driver's take from one ride. Net is Gross less
+// PlatformFees and Refunds.
+type RideEarnings struct {
+	RideID       string          `json:"rideId"`
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Date         string          `json:"date"`
+	Currency     string          `json:"currency"`
+	Gross        decimal.Decimal `json:"gross"`
+	PlatformFees decimal.Decimal `json:"platformFees"`
+	Refunds      decimal.Decimal `json:"refunds"`
+	Net          decimal.Decimal `json:"net"`
+}
+
+// CurrencyEarnings is a driver's account in one currency.
+type CurrencyEarnings struct {
+	Currency    string          `json:"currency"`
+	TotalEarned decimal.Decimal `json:"totalEarned"`
+	PaidOut     decimal.Decimal `json:"paidOut"`
+	Balance     decimal.Decimal `json:"balance"`
+}
+
+// Earnings summarizes a driver's account. Amounts in different currencies
+// are never added together, so Totals has one entry per currency.
+type Earnings struct {
+	Totals []CurrencyEarnings `json:"totals"`
+	Rides  []RideEarnings     `json:"rides"`
+}
+
+// Earnings returns a driver's balances by currency and per-ride breakdown,
+// most recent rides first.
+func (s *LedgerService) Earnings(ctx context.Context, driverID string) (*Earnings, error) {
+	db := s.db.WithContext(ctx)
+	account := models.DriverAccount(driverID)
+
+	var txns []models.LedgerTransaction
+	if err := db.Preload("Entries").
+		Where("id IN (?)", db.Model(&models.LedgerEntry{}).Select("transaction_id").Where("account = ?", account)).
+		Order("created_at DESC").Find(&txns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get ledger transactions: %v", err)
+	}
+
+	totals := map[string]*CurrencyEarnings{}
+	total := func(currency string) *CurrencyEarnings {
+		t, ok := totals[currency]
+		if !ok {
+			t = &CurrencyEarnings{Currency: currency}
+			totals[currency] = t
+		}
+		return t
+	}
+
+	earnings := &Earnings{Totals: []CurrencyEarnings{}, Rides: []RideEarnings{}}
+	byRide := map[string]*RideEarnings{}
+	var rideIDs []string
+	for _, txn := range txns {
+		for _, entry := range txn.Entries {
+			if entry.Account == account {
+				t := total(entry.Currency)
+				t.Balance = t.Balance.Sub(entry.Amount)
+				if txn.Kind == models.LedgerPayout {
+					t.PaidOut = t.PaidOut.Add(entry.Amount)
+				}
+			}
+		}
+		if txn.Kind == models.LedgerPayout || txn.RideID == "" {
+			continue
+		}
+
+		ride, ok := byRide[txn.RideID]
+		if !ok {
+			ride = &RideEarnings{RideID: txn.RideID}
+			byRide[txn.RideID] = ride
+			rideIDs = append(rideIDs, txn.RideID)
+		}
+		for _, entry := range txn.Entries {
+			switch {
+			case entry.Account == account:
+				ride.Currency = entry.Currency
+				ride.Net = ride.Net.Sub(entry.Amount)
+			case entry.Account == models.AccountPlatformRevenue:
+				ride.PlatformFees = ride.PlatformFees.Sub(entry.Amount)
+			case entry.Account == models.AccountPlatformCash && txn.Kind == models.LedgerCapture:
+				ride.Gross = ride.Gross.Add(entry.Amount)
+			case entry.Account == models.AccountPlatformCash && txn.Kind == models.LedgerRefund:
+				ride.Refunds = ride.Refunds.Sub(entry.Amount)
+			}
+		}
+	}
+
+	if len(rideIDs) > 0 {
+		var rides []models.Ride
+		if err := db.Where("id IN ?", rideIDs).Find(&rides).Error; err != nil {
+			return nil, fmt.Errorf("failed to get rides: %v", err)
+		}
+		for _, r := range rides {
+			byRide[r.ID].From, byRide[r.ID].To, byRide[r.ID].Date = r.From, r.To, r.DepartureDate()
+		}
+	}
+	for _, id := range rideIDs {
+		t := total(byRide[id].Currency)
+		t.TotalEarned = t.TotalEarned.Add(byRide[id].Net)
+		earnings.Rides = append(earnings.Rides, *byRide[id])
+	}
+
+	for _, t := range totals {
+		earnings.Totals = append(earnings.Totals, *t)
+	}
+	sort.Slice(earnings.Totals, func(i, j int) bool { return earnings.Totals[i].Currency < earnings.Totals[j].Currency })
+	return earnings, nil
+}
+
+// PayoutLine is one driver's share of a payout batch.
+type PayoutLine struct {
+	DriverID string
+	Amount   decimal.Decimal
+	Currency string
+}
+
+// PayoutBatchLines is a posted payout batch with its per-driver lines.
+type PayoutBatchLines struct {
+	Batch *models.PayoutBatch
+	Lines []PayoutLine
+}
+
+// CreatePayoutBatches pays out every driver balance of at least minimum,
+// posting a payout transaction for each. Balances are batched per currency
+// since a bank transfer file carries one. It returns nil when nobody is due
+// a payout.
+func (s *LedgerService) CreatePayoutBatches(ctx context.Context, minimum decimal.Decimal) ([]PayoutBatchLines, error) {
+	var batches []PayoutBatchLines
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize batches so a balance is never paid twice
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('ledger_payouts'))").Error; err != nil {
+			return fmt.Errorf("failed to lock payouts: %v", err)
+		}
+
+		var balances []struct {
+			Account  string
+			Currency string
+			Balance  decimal.Decimal
+		}
+		if err := tx.Model(&models.LedgerEntry{}).
+			Select("account, currency, -SUM(amount) AS balance").
+			Where("account LIKE ?", models.DriverAccount("%")).
+			Group("account, currency").
+			Having("-SUM(amount) >= ?", minimum).
+			Order("currency, account").
+			Scan(&balances).Error; err != nil {
+			return fmt.Errorf("failed to get driver balances: %v", err)
+		}
+
+		for start := 0; start < len(balances); {
+			currency := balances[start].Currency
+			end := start
+			batch := &models.PayoutBatch{Currency: currency, CreatedAt: time.Now()}
+			for ; end < len(balances) && balances[end].Currency == currency; end++ {
+				batch.Total = batch.Total.Add(balances[end].Balance)
+				batch.Payouts++
+			}
+			if err := tx.Create(batch).Error; err != nil {
+				return fmt.Errorf("failed to create payout batch: %v", err)
+			}
+
+			var lines []PayoutLine
+			for _, b := range balances[start:end] {
+				driverID := strings.TrimPrefix(b.Account, models.DriverAccount(""))
+				if err := s.post(tx, &models.LedgerTransaction{
+					Kind:          models.LedgerPayout,
+					PayoutBatchID: batch.ID,
+					Description:   fmt.Sprintf("payout batch %s", batch.ID),
+					Entries: []models.LedgerEntry{
+						{Account: b.Account, Amount: b.Balance, Currency: b.Currency},
+						{Account: models.AccountPlatformCash, Amount: b.Balance.Neg(), Currency: b.Currency},
+					},
+				}); err != nil {
+					return err
+				}
+				lines = append(lines, PayoutLine{DriverID: driverID, Amount: b.Balance, Currency: b.Currency})
+			}
+			batches = append(batches, PayoutBatchLines{Batch: batch, Lines: lines})
+			start = end
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// SetExportPath records where a batch was exported.
+func (s *LedgerService) SetExportPath(ctx context.Context, batch *models.PayoutBatch, path string) error {
+	batch.ExportPath = path
+	if err := s.db.WithContext(ctx).Model(batch).Update("export_path", path).Error; err != nil {
+		return fmt.Errorf("failed to update payout batch: %v", err)
+	}
+	return nil
+}
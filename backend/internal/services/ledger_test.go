@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLedgerSplitEntries(t *testing.T) {
+	tests := []struct {
+		name        string
+		feePercent  float64
+		amount      string
+		wantDriver  string
+		wantRevenue string
+	}{
+		{"capture", 10, "25.00", "-22.50", "-2.50"},
+		{"refund", 10, "-25.00", "22.50", "2.50"},
+		{"fee rounds to cents", 12.5, "9.99", "-8.74", "-1.25"},
+		{"no fee", 0, "40", "-40", "0"},
+		{"whole fee", 100, "15", "0", "-15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := NewLedgerService(nil, tt.feePercent)
+			entries := ledger.splitEntries(decimal.RequireFromString(tt.amount), "driver-1", "USD")
+
+			want := map[string]string{
+				models.AccountPlatformCash:       tt.amount,
+				models.DriverAccount("driver-1"): tt.wantDriver,
+				models.AccountPlatformRevenue:    tt.wantRevenue,
+			}
+			sum := decimal.Zero
+			for _, entry := range entries {
+				if !entry.Amount.Equal(decimal.RequireFromString(want[entry.Account])) {
+					t.Errorf("%s = %s, want %s", entry.Account, entry.Amount, want[entry.Account])
+				}
+				if entry.Currency != "USD" {
+					t.Errorf("%s currency = %s, want USD", entry.Account, entry.Currency)
+				}
+				sum = sum.Add(entry.Amount)
+			}
+			if len(entries) != len(want) {
+				t.Errorf("got %d entries, want %d", len(entries), len(want))
+			}
+			if !sum.IsZero() {
+				t.Errorf("entries sum to %s, want 0", sum)
+			}
+		})
+	}
+}
+
+func TestLedgerPostRejectsUnbalanced(t *testing.T) {
+	ledger := NewLedgerService(nil, 10)
+	err := ledger.post(nil, &models.LedgerTransaction{
+		Kind: models.LedgerCapture,
+		Entries: []models.LedgerEntry{
+			{Account: models.AccountPlatformCash, Amount: decimal.NewFromInt(10), Currency: "USD"},
+			{Account: models.DriverAccount("driver-1"), Amount: decimal.NewFromInt(-9), Currency: "USD"},
+		},
+	})
+	if err == nil {
+		t.Fatal("post() accepted an unbalanced transaction")
+	}
+}
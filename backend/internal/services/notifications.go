@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	db *gorm.DB
+}
+
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+// Notify stores a notification for a user. Pass the surrounding transaction
+// as tx so the notification is only kept if the change it describes commits.
+func (s *NotificationService) Notify(tx *gorm.DB, notification *models.Notification) error {
+	if tx == nil {
+		tx = s.db
+	}
+	notification.CreatedAt = time.Now()
+	if err := tx.Create(notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %v", err)
+	}
+
+	slog.InfoContext(tx.Statement.Context, "notification created", "user_id", notification.UserID, "type", notification.Type)
+	return nil
+}
+
+// ListForUser returns a user's notifications, newest first.
+func (s *NotificationService) ListForUser(userID string) ([]models.Notification, error) {
+	var notifications []models.Notification
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead flags one of a user's notifications as read.
+func (s *NotificationService) MarkRead(userID, id string) error {
+	result := s.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read", true)
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark notification read: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
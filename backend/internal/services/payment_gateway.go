@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+// Errors returned by PaymentGateway implementations.
+var (
+	// ErrPaymentDeclined is returned when the payer's authorization fails.
+	ErrPaymentDeclined = errors.New("payment declined")
+	// ErrInvalidWebhook is returned for webhooks that fail verification.
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrAlreadyCaptured is returned when a capture of the same amount
+	// already went through, e.g. before a rolled back transaction.
+	ErrAlreadyCaptured = errors.New("payment already captured")
+)
+
+// PaymentGateway moves money through an external payment provider. Amounts
+// are in the currency given at authorization.
+type PaymentGateway interface {
+	// Name identifies the provider on stored payments.
+	Name() string
+	// Authorize places a hold for amount and returns the provider's
+	// reference. idempotencyKey makes retries safe.
+	Authorize(ctx context.Context, idempotencyKey string, amount decimal.Decimal, currency string) (string, error)
+	// Capture collects up to the authorized amount and releases the rest.
+	// Repeating a capture of the same amount returns ErrAlreadyCaptured.
+	Capture(ctx context.Context, reference string, amount decimal.Decimal) error
+	// Void releases an authorization without collecting anything.
+	Void(ctx context.Context, reference string) error
+	// Refund returns part or all of a captured amount.
+	Refund(ctx context.Context, reference string, amount decimal.Decimal) error
+	// ParseWebhook verifies a webhook request and decodes its event.
+	ParseWebhook(r *http.Request) (*WebhookEvent, error)
+}
+
+// Webhook event types understood by PaymentService.
+const (
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookPaymentRefunded = "payment.refunded"
+	WebhookPaymentFailed   = "payment.failed"
+	WebhookPaymentVoided   = "payment.voided"
+)
+
+// WebhookEvent is a provider notification about a payment. Amount is the
+// captured or refunded total where relevant.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Reference string          `json:"reference"`
+	Amount    decimal.Decimal `json:"amount"`
+}
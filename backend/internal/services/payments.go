@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/metrics"
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewPaymentGateway builds the gateway selected by cfg.PaymentProvider.
+func NewPaymentGateway(cfg *config.Config) (PaymentGateway, error) {
+	switch cfg.PaymentProvider {
+	case "fake":
+		return NewFakeGateway(cfg.PaymentWebhookSecret, decimal.NewFromFloat(cfg.FakePaymentDeclineAbove)), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
+
+// PaymentService keeps Payment rows in step with the gateway. Methods that
+// take a transaction must run inside the one that changes the booking, so
+// a gateway failure rolls the booking change back.
+type PaymentService struct {
+	db      *gorm.DB
+	gateway PaymentGateway
+	ledger  *LedgerService
+}
+
+func NewPaymentService(db *gorm.DB, gateway PaymentGateway, ledger *LedgerService) *PaymentService {
+	return &PaymentService{db: db, gateway: gateway, ledger: ledger}
+}
+
+// Authorize holds the booking's total with the gateway. It returns
+// ErrPaymentDeclined if the payer cannot cover it.
+func (s *PaymentService) Authorize(tx *gorm.DB, booking *models.Booking) (*models.Payment, error) {
+	ref, err := s.gateway.Authorize(tx.Statement.Context, booking.ID, booking.Total, booking.Currency)
+	if err != nil {
+		recordPayment("authorize", err)
+		if errors.Is(err, ErrPaymentDeclined) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to authorize payment: %v", err)
+	}
+	recordPayment("authorize", nil)
+
+	payment := &models.Payment{
+		BookingID:   booking.ID,
+		Provider:    s.gateway.Name(),
+		ProviderRef: ref,
+		Amount:      booking.Total,
+		Currency:    booking.Currency,
+		Status:      models.PaymentAuthorized,
+	}
+	if err := tx.Create(payment).Error; err != nil {
+		return nil, fmt.Errorf("failed to record payment: %v", err)
+	}
+	return payment, nil
+}
+
+// Capture collects the full authorized amount for a completed trip.
+// Bookings without an authorized payment are left alone. A capture that
+// went through before a rolled back attempt counts as done, so completing
+// a ride can be retried.
+func (s *PaymentService) Capture(tx *gorm.DB, bookingID string) error {
+	payment, err := s.lockPayment(tx, bookingID)
+	if err != nil || payment == nil || payment.Status != models.PaymentAuthorized {
+		return err
+	}
+
+	err = s.capture(tx.Statement.Context, payment.ProviderRef, payment.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to capture payment: %v", err)
+	}
+
+	payment.CapturedAmount = payment.Amount
+	payment.Status = models.PaymentCaptured
+	if err := s.ledger.RecordCapture(tx, payment, payment.CapturedAmount); err != nil {
+		return err
+	}
+	return s.save(tx, payment)
+}
+
+// Refund returns amount of the booking's payment to the passenger. An
+// uncaptured authorization is voided when refunded in full, or captured
+// for the retained part otherwise.
+func (s *PaymentService) Refund(tx *gorm.DB, bookingID string, amount decimal.Decimal) error {
+	payment, err := s.lockPayment(tx, bookingID)
+	if err != nil || payment == nil {
+		return err
+	}
+	ctx := tx.Statement.Context
+
+	switch payment.Status {
+	case models.PaymentAuthorized:
+		retained := payment.Amount.Sub(amount)
+		if !retained.IsPositive() {
+			err = s.gateway.Void(ctx, payment.ProviderRef)
+			recordPayment("void", err)
+			if err != nil {
+				return fmt.Errorf("failed to void payment: %v", err)
+			}
+			payment.Status = models.PaymentVoided
+			break
+		}
+		err = s.capture(ctx, payment.ProviderRef, retained)
+		if err != nil {
+			return fmt.Errorf("failed to capture retained amount: %v", err)
+		}
+		payment.CapturedAmount = retained
+		payment.Status = models.PaymentCaptured
+		if err := s.ledger.RecordCapture(tx, payment, retained); err != nil {
+			return err
+		}
+
+	case models.PaymentCaptured, models.PaymentPartiallyRefunded:
+		refundable := payment.CapturedAmount.Sub(payment.RefundedAmount)
+		if amount.GreaterThan(refundable) {
+			amount = refundable
+		}
+		if !amount.IsPositive() {
+			return nil
+		}
+		err = s.gateway.Refund(ctx, payment.ProviderRef, amount)
+		recordPayment("refund", err)
+		if err != nil {
+			return fmt.Errorf("failed to refund payment: %v", err)
+		}
+		if err := s.ledger.RecordRefund(tx, payment, amount); err != nil {
+			return err
+		}
+		payment.RefundedAmount = payment.RefundedAmount.Add(amount)
+		payment.Status = models.PaymentPartiallyRefunded
+		if payment.RefundedAmount.Equal(payment.CapturedAmount) {
+			payment.Status = models.PaymentRefunded
+		}
+
+	default:
+		return nil
+	}
+
+	return s.save(tx, payment)
+}
+
+// ReleaseHold voids a payment authorized in a transaction that then rolled
+// back, so the passenger is not left with a hold for a booking that does
+// not exist. Failures are logged; the hold then expires at the provider.
+func (s *PaymentService) ReleaseHold(ctx context.Context, payment *models.Payment) {
+	if payment == nil {
+		return
+	}
+	err := s.gateway.Void(ctx, payment.ProviderRef)
+	recordPayment("void", err)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to release payment hold", "booking_id", payment.BookingID, "error", err)
+	}
+}
+
+// HandleWebhook applies a gateway event once. Redelivered events are
+// reported as duplicates and change nothing.
+func (s *PaymentService) HandleWebhook(ctx context.Context, event *WebhookEvent) (duplicate bool, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record := models.PaymentEvent{
+			ID:          s.gateway.Name() + ":" + event.ID,
+			Type:        event.Type,
+			ProviderRef: event.Reference,
+			ProcessedAt: time.Now(),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record webhook: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return nil
+		}
+
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&payment, "provider = ? AND provider_ref = ?", s.gateway.Name(), event.Reference).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.WarnContext(ctx, "webhook for unknown payment", "event_type", event.Type)
+				return nil
+			}
+			return fmt.Errorf("failed to get payment: %v", err)
+		}
+
+		// Events can arrive late or out of order, so each one only moves
+		// the payment forward and only posts what the ledger is missing
+		switch event.Type {
+		case WebhookPaymentCaptured:
+			if payment.Status != models.PaymentAuthorized && payment.Status != models.PaymentCaptured {
+				return s.ignoreWebhook(ctx, event, &payment)
+			}
+			captured := event.Amount.Sub(payment.CapturedAmount)
+			if !captured.IsPositive() {
+				return s.ignoreWebhook(ctx, event, &payment)
+			}
+			if err := s.ledger.RecordCapture(tx, &payment, captured); err != nil {
+				return err
+			}
+			payment.CapturedAmount = event.Amount
+			payment.Status = models.PaymentCaptured
+		case WebhookPaymentRefunded:
+			if payment.Status != models.PaymentCaptured && payment.Status != models.PaymentPartiallyRefunded {
+				return s.ignoreWebhook(ctx, event, &payment)
+			}
+			refunded := decimal.Min(event.Amount, payment.CapturedAmount).Sub(payment.RefundedAmount)
+			if !refunded.IsPositive() {
+				return s.ignoreWebhook(ctx, event, &payment)
+			}
+			if err := s.ledger.RecordRefund(tx, &payment, refunded); err != nil {
+				return err
+			}
+			payment.RefundedAmount = payment.RefundedAmount.Add(refunded)
+			payment.Status = models.PaymentPartiallyRefunded
+			if payment.RefundedAmount.Equal(payment.CapturedAmount) {
+				payment.Status = models.PaymentRefunded
+			}
+		case WebhookPaymentVoided, WebhookPaymentFailed:
+			if payment.Status != models.PaymentAuthorized {
+				return s.ignoreWebhook(ctx, event, &payment)
+			}
+			payment.Status = models.PaymentVoided
+			if event.Type == WebhookPaymentFailed {
+				payment.Status = models.PaymentFailed
+			}
+		default:
+			slog.DebugContext(ctx, "ignoring webhook", "event_type", event.Type)
+			return nil
+		}
+		return s.save(tx, &payment)
+	})
+	return duplicate, err
+}
+
+// ignoreWebhook logs an event that would move the payment backwards or
+// repeats what is already recorded.
+func (s *PaymentService) ignoreWebhook(ctx context.Context, event *WebhookEvent, payment *models.Payment) error {
+	slog.InfoContext(ctx, "ignoring stale webhook", "event_type", event.Type, "booking_id", payment.BookingID, "payment_status", payment.Status)
+	return nil
+}
+
+// ParseWebhook verifies and decodes a gateway webhook request.
+func (s *PaymentService) ParseWebhook(r *http.Request) (*WebhookEvent, error) {
+	return s.gateway.ParseWebhook(r)
+}
+
+// lockPayment returns the booking's payment locked for update, or nil for
+// bookings made before payments existed.
+func (s *PaymentService) lockPayment(tx *gorm.DB, bookingID string) (*models.Payment, error) {
+	var payment models.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "booking_id = ?", bookingID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %v", err)
+	}
+	return &payment, nil
+}
+
+// capture collects amount at the gateway, treating a repeat of an earlier
+// capture as success.
+func (s *PaymentService) capture(ctx context.Context, reference string, amount decimal.Decimal) error {
+	err := s.gateway.Capture(ctx, reference, amount)
+	if errors.Is(err, ErrAlreadyCaptured) {
+		err = nil
+	}
+	recordPayment("capture", err)
+	return err
+}
+
+func (s *PaymentService) save(tx *gorm.DB, payment *models.Payment) error {
+	payment.UpdatedAt = time.Now()
+	if err := tx.Save(payment).Error; err != nil {
+		return fmt.Errorf("failed to update payment: %v", err)
+	}
+	return nil
+}
+
+func recordPayment(operation string, err error) {
+	outcome := "success"
+	switch {
+	case errors.Is(err, ErrPaymentDeclined):
+		outcome = "declined"
+	case err != nil:
+		outcome = "error"
+	}
+	metrics.PaymentOperations.WithLabelValues(operation, outcome).Inc()
+}
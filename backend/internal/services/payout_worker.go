@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// PayoutWorker periodically pays out driver balances and exports each
+// batch as a CSV file for the finance team to submit to the bank.
+type PayoutWorker struct {
+	ledger    *LedgerService
+	exportDir string
+	minimum   decimal.Decimal
+	interval  time.Duration
+}
+
+func NewPayoutWorker(ledger *LedgerService, exportDir string, minimum decimal.Decimal, interval time.Duration) *PayoutWorker {
+	return &PayoutWorker{ledger: ledger, exportDir: exportDir, minimum: minimum, interval: interval}
+}
+
+// Run creates payout batches every interval until ctx is cancelled.
+func (w *PayoutWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.runBatch(ctx); err != nil {
+				slog.ErrorContext(ctx, "failed to create payout batch", "error", err)
+			}
+		}
+	}
+}
+
+func (w *PayoutWorker) runBatch(ctx context.Context) error {
+	batches, err := w.ledger.CreatePayoutBatches(ctx, w.minimum)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		slog.InfoContext(ctx, "no driver balances due for payout")
+		return nil
+	}
+
+	var errs []error
+	for _, b := range batches {
+		path, err := w.export(b.Batch, b.Lines)
+		if err != nil {
+			// The batch is already posted; the file can be regenerated from
+			// the ledger, so report rather than undo
+			errs = append(errs, fmt.Errorf("failed to export payout batch %s: %v", b.Batch.ID, err))
+			continue
+		}
+		if err := w.ledger.SetExportPath(ctx, b.Batch, path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		slog.InfoContext(ctx, "payout batch exported", "batch_id", b.Batch.ID, "currency", b.Batch.Currency, "payouts", b.Batch.Payouts, "path", path)
+	}
+	return errors.Join(errs...)
+}
+
+func (w *PayoutWorker) export(batch *models.PayoutBatch, lines []PayoutLine) (string, error) {
+	if err := os.MkdirAll(w.exportDir, 0o750); err != nil {
+		return "", err
+	}
+	path := filepath.Join(w.exportDir, fmt.Sprintf("payouts-%s-%s.csv", batch.CreatedAt.Format("20060102"), batch.ID))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	out := csv.NewWriter(file)
+	out.Write([]string{"batch_id", "driver_id", "amount", "currency"})
+	for _, line := range lines {
+		out.Write([]string{batch.ID, line.DriverID, line.Amount.StringFixed(2), line.Currency})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return "", err
+	}
+	return path, file.Close()
+}
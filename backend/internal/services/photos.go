@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"ride_sharing/backend/internal/models"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// Errors returned by PhotoService.UploadProfilePhoto.
+var (
+	// ErrPhotoTooLarge is returned for uploads above the size limit or
+	// with more pixels than we are willing to decode.
+	ErrPhotoTooLarge = errors.New("photo too large")
+	// ErrUnsupportedPhoto is returned for files that are not a JPEG, PNG,
+	// GIF or WebP image.
+	ErrUnsupportedPhoto = errors.New("unsupported photo type")
+)
+
+const (
+	photoSize     = 512 // longest side of the stored profile photo
+	thumbnailSize = 128 // longest side of the thumbnail
+	photoQuality  = 85
+	// Refuse to decode images whose pixel buffer alone would be huge
+	maxPhotoPixels = 40_000_000
+)
+
+var photoTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// PhotoService stores profile photos. Uploads are re-encoded as JPEG at a
+// fixed size plus a thumbnail, which also strips any embedded metadata.
+type PhotoService struct {
+	db       *gorm.DB
+	storage  Storage
+	maxBytes int64
+}
+
+func NewPhotoService(db *gorm.DB, storage Storage, maxBytes int64) *PhotoService {
+	return &PhotoService{db: db, storage: storage, maxBytes: maxBytes}
+}
+
+// MaxBytes is the largest upload accepted.
+func (s *PhotoService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// UploadProfilePhoto replaces a user's profile photo with the image read
+// from r and returns the updated user.
+func (s *PhotoService) UploadProfilePhoto(ctx context.Context, userID string, r io.Reader) (*models.User, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %v", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrPhotoTooLarge
+	}
+	if !photoTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedPhoto
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedPhoto
+	}
+	if cfg.Width*cfg.Height > maxPhotoPixels {
+		return nil, ErrPhotoTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedPhoto
+	}
+
+	id, err := newPhotoID()
+	if err != nil {
+		return nil, err
+	}
+	photoKey := fmt.Sprintf("users/%s/%s.jpg", userID, id)
+	thumbKey := thumbnailKey(photoKey)
+	if err := s.store(ctx, photoKey, resize(img, photoSize)); err != nil {
+		return nil, err
+	}
+	if err := s.store(ctx, thumbKey, resize(img, thumbnailSize)); err != nil {
+		s.storage.Delete(ctx, photoKey)
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		s.deleteFiles(ctx, photoKey, thumbKey)
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+	previous := photoKeys(user.PhotoKey)
+
+	photoURL, thumbURL := s.storage.URL(photoKey), s.storage.URL(thumbKey)
+	changes := map[string]interface{}{
+		"profile_image":     photoURL,
+		"profile_thumbnail": thumbURL,
+		"photo_key":         photoKey,
+	}
+	if err := s.db.WithContext(ctx).Model(&user).Updates(changes).Error; err != nil {
+		s.deleteFiles(ctx, photoKey, thumbKey)
+		return nil, fmt.Errorf("failed to update user: %v", err)
+	}
+	user.ProfileImage, user.ProfileThumbnail, user.PhotoKey = &photoURL, &thumbURL, photoKey
+
+	// Old files are no longer referenced; failing to remove them only
+	// wastes space
+	s.deleteFiles(ctx, previous...)
+	return &user, nil
+}
+
+func (s *PhotoService) store(ctx context.Context, key string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: photoQuality}); err != nil {
+		return fmt.Errorf("failed to encode photo: %v", err)
+	}
+	return s.storage.Put(ctx, key, &buf, int64(buf.Len()), "image/jpeg")
+}
+
+func (s *PhotoService) deleteFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "failed to delete stored photo", "path", key, "error", err)
+		}
+	}
+}
+
+// resize scales img so its longest side is at most size, flattening any
+// transparency onto white.
+func resize(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// photoKeys returns the stored files of an uploaded photo, none for users
+// without one or whose photo came from their sign-in provider.
+func photoKeys(photoKey string) []string {
+	if photoKey == "" {
+		return nil
+	}
+	return []string{photoKey, thumbnailKey(photoKey)}
+}
+
+func thumbnailKey(photoKey string) string {
+	return strings.TrimSuffix(photoKey, ".jpg") + "_thumb.jpg"
+}
+
+func newPhotoID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate photo id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
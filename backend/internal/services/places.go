@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/metrics"
+	"ride_sharing/backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Errors returned by PlacesProvider implementations.
+var (
+	// ErrPlaceNotFound is returned when a place ID does not resolve.
+	ErrPlaceNotFound = errors.New("place not found")
+	// ErrPlacesQuotaExceeded is returned when the upstream API throttles us.
+	ErrPlacesQuotaExceeded = errors.New("places quota exceeded")
+	// ErrPlacesDenied is returned when the upstream API rejects our key.
+	ErrPlacesDenied = errors.New("places request denied")
+)
+
+// PlacesProvider looks up places. Implementations are restricted to the
+// configured country.
+type PlacesProvider interface {
+	// Autocomplete suggests places for a partial input. sessionToken, when
+	// set, groups the keystrokes of one search for billing.
+	Autocomplete(ctx context.Context, input, sessionToken string) ([]Prediction, error)
+	// PlaceDetails resolves a prediction's place ID.
+	PlaceDetails(ctx context.Context, placeID, sessionToken string) (*Place, error)
+	// Geocode finds places matching a free-form address.
+	Geocode(ctx context.Context, address string) ([]Place, error)
+	// ReverseGeocode finds the places at or nearest to a coordinate.
+	ReverseGeocode(ctx context.Context, lat, lng float64) ([]Place, error)
+}
+
+// Prediction is a single autocomplete suggestion.
+type Prediction struct {
+	PlaceID       string
+	Description   string
+	MainText      string
+	SecondaryText string
+}
+
+// Place is a resolved location.
+type Place struct {
+	PlaceID          string
+	Name             string
+	FormattedAddress string
+	Lat              float64
+	Lng              float64
+}
+
+// NewPlacesProvider builds the provider selected by cfg.PlacesProvider.
+func NewPlacesProvider(cfg *config.Config) (PlacesProvider, error) {
+	switch cfg.PlacesProvider {
+	case "google":
+		return NewGooglePlacesProvider(cfg), nil
+	case "gazetteer":
+		return NewGazetteerProvider(cfg.PlacesGazetteerFile, cfg.PlacesCountry)
+	default:
+		return nil, fmt.Errorf("unknown places provider %q", cfg.PlacesProvider)
+	}
+}
+
+// PlacesService fronts a PlacesProvider with tracing and caches for
+// autocomplete, keyed by normalized input, and place details.
+type PlacesService struct {
+	provider PlacesProvider
+	cache    *TTLCache[[]Prediction]
+	details  *TTLCache[*Place]
+}
+
+func NewPlacesService(provider PlacesProvider, cfg *config.Config) *PlacesService {
+	return &PlacesService{
+		provider: provider,
+		cache:    NewTTLCache[[]Prediction](cfg.PlacesCacheSize, cfg.PlacesCacheTTL),
+		details:  NewTTLCache[*Place](cfg.PlacesCacheSize, cfg.PlacesCacheTTL),
+	}
+}
+
+func (s *PlacesService) Autocomplete(ctx context.Context, input, sessionToken string) (predictions []Prediction, err error) {
+	ctx, span := startPlacesSpan(ctx, "places.autocomplete")
+	defer func() { endPlacesSpan(span, err) }()
+	span.SetAttributes(attribute.Int("places.input_length", len(input)))
+
+	cacheKey := normalizeInput(input)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		metrics.PlacesCacheLookups.WithLabelValues("autocomplete", "hit").Inc()
+		span.SetAttributes(attribute.Bool("places.cache_hit", true))
+		return cached, nil
+	}
+	metrics.PlacesCacheLookups.WithLabelValues("autocomplete", "miss").Inc()
+
+	predictions, err = s.provider.Autocomplete(ctx, input, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(cacheKey, predictions)
+	return predictions, nil
+}
+
+func (s *PlacesService) PlaceDetails(ctx context.Context, placeID, sessionToken string) (place *Place, err error) {
+	ctx, span := startPlacesSpan(ctx, "places.details")
+	defer func() { endPlacesSpan(span, err) }()
+
+	if cached, ok := s.details.Get(placeID); ok {
+		metrics.PlacesCacheLookups.WithLabelValues("details", "hit").Inc()
+		span.SetAttributes(attribute.Bool("places.cache_hit", true))
+		return cached, nil
+	}
+	metrics.PlacesCacheLookups.WithLabelValues("details", "miss").Inc()
+
+	place, err = s.provider.PlaceDetails(ctx, placeID, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	s.details.Set(placeID, place)
+	return place, nil
+}
+
+func (s *PlacesService) Geocode(ctx context.Context, address string) (places []Place, err error) {
+	ctx, span := startPlacesSpan(ctx, "places.geocode")
+	defer func() { endPlacesSpan(span, err) }()
+	return s.provider.Geocode(ctx, address)
+}
+
+func (s *PlacesService) ReverseGeocode(ctx context.Context, lat, lng float64) (places []Place, err error) {
+	ctx, span := startPlacesSpan(ctx, "places.reverse_geocode")
+	defer func() { endPlacesSpan(span, err) }()
+	return s.provider.ReverseGeocode(ctx, lat, lng)
+}
+
+func startPlacesSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer("ride_sharing/backend/places").Start(ctx, name)
+}
+
+func endPlacesSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// normalizeInput folds case and whitespace so "New  York" and "new york"
+// share a cache entry.
+func normalizeInput(input string) string {
+	return strings.ToLower(strings.Join(strings.Fields(input), " "))
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// FareEstimate is a per-seat price suggestion for a route. MaxPrice is
+// zero when listings are not capped.
+type FareEstimate struct {
+	SuggestedPrice decimal.Decimal
+	MinPrice       decimal.Decimal
+	MaxPrice       decimal.Decimal
+	Currency       string
+}
+
+// PricingService suggests per-seat fares from the cost of driving a route,
+// split between the driver and passengers, and prices bookings.
+type PricingService struct {
+	currency      string
+	costPerKm     float64
+	costPerMinute float64
+	fuelPrice     float64
+	fuelPer100Km  float64
+	costShares    float64
+	maxMarkup     float64
+	minFare       decimal.Decimal
+	maxFare       decimal.Decimal
+	feePercent    decimal.Decimal
+	feeFixed      decimal.Decimal
+}
+
+func NewPricingService(cfg *config.Config) *PricingService {
+	return &PricingService{
+		currency:      cfg.Currency,
+		costPerKm:     cfg.PricingCostPerKm,
+		costPerMinute: cfg.PricingCostPerMinute,
+		fuelPrice:     cfg.PricingFuelPricePerLitre,
+		fuelPer100Km:  cfg.PricingFuelLitresPer100Km,
+		costShares:    float64(cfg.PricingCostShares),
+		maxMarkup:     cfg.PricingMaxMarkup,
+		minFare:       decimal.NewFromFloat(cfg.PricingMinFare).Round(2),
+		maxFare:       decimal.NewFromFloat(cfg.PricingMaxFare).Round(2),
+		feePercent:    decimal.NewFromFloat(cfg.BookingFeePercent),
+		feeFixed:      decimal.NewFromFloat(cfg.BookingFeeFixed).Round(2),
+	}
+}
+
+// Currency is the ISO 4217 code new rides are priced in.
+func (p *PricingService) Currency() string {
+	return p.currency
+}
+
+// Estimate prices a route. Without a route only the configured minimum and
+// maximum fares apply.
+func (p *PricingService) Estimate(route *RouteEstimate) FareEstimate {
+	estimate := FareEstimate{MinPrice: p.minFare, MaxPrice: p.maxFare, Currency: p.currency}
+	if route == nil {
+		estimate.SuggestedPrice = p.minFare
+		return estimate
+	}
+
+	km := float64(route.DistanceMeters) / 1000
+	tripCost := km*(p.costPerKm+p.fuelPrice*p.fuelPer100Km/100) + route.Duration.Minutes()*p.costPerMinute
+	estimate.SuggestedPrice = p.clamp(decimal.NewFromFloat(tripCost / p.costShares).Round(2))
+
+	// Drivers may round up, but not profit far beyond their costs
+	if p.maxMarkup > 0 {
+		markupCap := p.clamp(estimate.SuggestedPrice.Mul(decimal.NewFromFloat(p.maxMarkup)).Round(2))
+		if estimate.MaxPrice.IsZero() || markupCap.LessThan(estimate.MaxPrice) {
+			estimate.MaxPrice = markupCap
+		}
+	}
+	return estimate
+}
+
+// PriceBooking snapshots the ride's current per-seat price onto the
+// booking along with fees and the total charged.
+func (p *PricingService) PriceBooking(booking *models.Booking, ride *models.Ride) {
+	subtotal := ride.Price.Mul(decimal.NewFromInt(int64(booking.Passengers)))
+	fees := p.feeFixed.Add(subtotal.Mul(p.feePercent).Div(decimal.NewFromInt(100))).Round(2)
+
+	booking.UnitPrice = ride.Price
+	booking.Fees = fees
+	booking.Total = subtotal.Add(fees)
+	booking.Currency = ride.Currency
+	if booking.Currency == "" {
+		booking.Currency = models.DefaultCurrency
+	}
+}
+
+func (p *PricingService) clamp(price decimal.Decimal) decimal.Decimal {
+	if price.LessThan(p.minFare) {
+		price = p.minFare
+	}
+	if p.maxFare.IsPositive() && price.GreaterThan(p.maxFare) {
+		price = p.maxFare
+	}
+	return price
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPricingEstimate(t *testing.T) {
+	base := config.Config{
+		Currency:          "EUR",
+		PricingCostPerKm:  0.10,
+		PricingCostShares: 4,
+		PricingMaxMarkup:  1.5,
+		PricingMinFare:    2,
+	}
+	capped := base
+	capped.PricingMaxFare = 3
+
+	tests := []struct {
+		name          string
+		cfg           config.Config
+		route         *RouteEstimate
+		wantSuggested string
+		wantMax       string
+	}{
+		{"unknown route", base, nil, "2", "0"},
+		{"cost split between seats", base, &RouteEstimate{DistanceMeters: 100000, Duration: time.Hour}, "2.50", "3.75"},
+		{"raised to the minimum", base, &RouteEstimate{DistanceMeters: 10000, Duration: 10 * time.Minute}, "2", "3"},
+		{"capped at the maximum", capped, &RouteEstimate{DistanceMeters: 1000000, Duration: 10 * time.Hour}, "3", "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewPricingService(&tt.cfg).Estimate(tt.route)
+			if !got.SuggestedPrice.Equal(decimal.RequireFromString(tt.wantSuggested)) {
+				t.Errorf("SuggestedPrice = %s, want %s", got.SuggestedPrice, tt.wantSuggested)
+			}
+			if !got.MaxPrice.Equal(decimal.RequireFromString(tt.wantMax)) {
+				t.Errorf("MaxPrice = %s, want %s", got.MaxPrice, tt.wantMax)
+			}
+			if !got.MinPrice.Equal(decimal.NewFromInt(2)) || got.Currency != "EUR" {
+				t.Errorf("MinPrice, Currency = %s, %s, want 2, EUR", got.MinPrice, got.Currency)
+			}
+		})
+	}
+}
+
+func TestPricingPriceBooking(t *testing.T) {
+	pricing := NewPricingService(&config.Config{BookingFeePercent: 5, BookingFeeFixed: 0.5})
+	booking := &models.Booking{Passengers: 3}
+	pricing.PriceBooking(booking, &models.Ride{Price: decimal.RequireFromString("12.50")})
+
+	// 37.50 for the seats, fees 0.50 + 5% = 2.375, rounded to 2.38
+	if !booking.Fees.Equal(decimal.RequireFromString("2.38")) || !booking.Total.Equal(decimal.RequireFromString("39.88")) {
+		t.Errorf("Fees, Total = %s, %s, want 2.38, 39.88", booking.Fees, booking.Total)
+	}
+	if booking.Currency != models.DefaultCurrency {
+		t.Errorf("Currency = %s, want %s", booking.Currency, models.DefaultCurrency)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReconfirmationWorker cancels bookings whose passengers did not respond to a
+// ride change before the ride departed.
+type ReconfirmationWorker struct {
+	db            *gorm.DB
+	notifications *NotificationService
+	payments      *PaymentService
+	policy        *CancellationPolicy
+	interval      time.Duration
+}
+
+func NewReconfirmationWorker(db *gorm.DB, notifications *NotificationService, payments *PaymentService, policy *CancellationPolicy, interval time.Duration) *ReconfirmationWorker {
+	return &ReconfirmationWorker{db: db, notifications: notifications, payments: payments, policy: policy, interval: interval}
+}
+
+// Run checks for expired bookings every interval until ctx is cancelled.
+func (w *ReconfirmationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.cancelExpired(ctx); err != nil {
+				slog.ErrorContext(ctx, "failed to auto-cancel unconfirmed bookings", "error", err)
+			}
+		}
+	}
+}
+
+func (w *ReconfirmationWorker) cancelExpired(ctx context.Context) error {
+	db := w.db.WithContext(ctx)
+
+	var ids []string
+	if err := db.Model(&models.Booking{}).Where("status = ? AND reconfirm_by <= ?", models.BookingNeedsReconfirmation, time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to find expired bookings: %v", err)
+	}
+
+	for _, id := range ids {
+		cancelled := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// The passenger may have answered since the scan, so re-check
+			// the booking under lock before cancelling it
+			ride, booking, err := LockBooking(tx, id)
+			if err != nil || booking == nil {
+				return err
+			}
+			if booking.Status != models.BookingNeedsReconfirmation || booking.ReconfirmBy == nil || booking.ReconfirmBy.After(time.Now()) {
+				return nil
+			}
+
+			cancelled = true
+			if err := CancelBooking(tx, w.payments, w.policy, ride, booking, CancelledByRideChange); err != nil {
+				return err
+			}
+			return w.notifications.Notify(tx, &models.Notification{
+				UserID:    booking.PassengerID,
+				Type:      models.NotificationBookingCancelled,
+				Message:   "Your booking was cancelled because the ride changed and you did not confirm before departure.",
+				RideID:    booking.RideID,
+				BookingID: booking.ID,
+			})
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to auto-cancel booking", "booking_id", id, "error", err)
+			continue
+		}
+		if cancelled {
+			slog.InfoContext(ctx, "auto-cancelled unconfirmed booking", "booking_id", id)
+		}
+	}
+
+	return nil
+}
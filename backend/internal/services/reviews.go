@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotBookingParty is returned when the reviewer is neither the
+	// booking's passenger nor its ride's driver.
+	ErrNotBookingParty = errors.New("reviewer is not part of this booking")
+	// ErrBookingNotCompleted is returned when reviewing a booking whose
+	// ride has not been completed.
+	ErrBookingNotCompleted = errors.New("booking is not completed")
+	// ErrAlreadyReviewed is returned when the reviewer has already
+	// reviewed the booking.
+	ErrAlreadyReviewed = errors.New("booking already reviewed")
+)
+
+type ReviewService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewReviewService(db *gorm.DB, notifications *NotificationService) *ReviewService {
+	return &ReviewService{db: db, notifications: notifications}
+}
+
+// Submit records reviewerID's rating of the other side of a completed
+// booking and refreshes the reviewee's average rating. The passenger
+// reviews the driver and the driver reviews the passenger.
+func (s *ReviewService) Submit(ctx context.Context, bookingID, reviewerID string, rating int, comment string) (*models.Review, error) {
+	var review *models.Review
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.First(&booking, "id = ?", bookingID).Error; err != nil {
+			return err
+		}
+		var ride models.Ride
+		if err := tx.First(&ride, "id = ?", booking.RideID).Error; err != nil {
+			return fmt.Errorf("failed to get ride: %v", err)
+		}
+
+		review = &models.Review{
+			BookingID:  booking.ID,
+			RideID:     ride.ID,
+			ReviewerID: reviewerID,
+			Rating:     rating,
+			Comment:    strings.TrimSpace(comment),
+			CreatedAt:  time.Now(),
+		}
+		switch reviewerID {
+		case booking.PassengerID:
+			review.RevieweeID, review.Role = ride.Driver, models.ReviewOfDriver
+		case ride.Driver:
+			review.RevieweeID, review.Role = booking.PassengerID, models.ReviewOfPassenger
+		default:
+			return ErrNotBookingParty
+		}
+		if booking.Status != models.BookingCompleted {
+			return ErrBookingNotCompleted
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(review)
+		if result.Error != nil {
+			return fmt.Errorf("failed to create review: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyReviewed
+		}
+
+		if err := s.refreshRating(tx, review.RevieweeID); err != nil {
+			return err
+		}
+		return s.notifications.Notify(tx, &models.Notification{
+			UserID:    review.RevieweeID,
+			Type:      models.NotificationReviewReceived,
+			Message:   fmt.Sprintf("You received a %d-star review.", rating),
+			RideID:    ride.ID,
+			BookingID: booking.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// refreshRating recomputes a user's average rating from their reviews. The
+// user row is locked first so that a concurrent review waits for this one
+// to commit and then recomputes from a snapshot that includes it.
+func (s *ReviewService) refreshRating(tx *gorm.DB, userID string) error {
+	if err := tx.Exec(`SELECT 1 FROM users WHERE id = ? FOR UPDATE`, userID).Error; err != nil {
+		return fmt.Errorf("failed to lock user: %v", err)
+	}
+	err := tx.Exec(`UPDATE users SET
+		rating_average = (SELECT ROUND(AVG(rating), 2) FROM reviews WHERE reviewee_id = ?),
+		rating_count = (SELECT COUNT(*) FROM reviews WHERE reviewee_id = ?)
+		WHERE id = ?`, userID, userID, userID).Error
+	if err != nil {
+		return fmt.Errorf("failed to update rating: %v", err)
+	}
+	return nil
+}
+
+// ListForUser returns the reviews a user has received, newest first.
+func (s *ReviewService) ListForUser(ctx context.Context, userID string) ([]models.Review, error) {
+	var reviews []models.Review
+	if err := s.db.WithContext(ctx).Where("reviewee_id = ?", userID).Order("created_at DESC").Find(&reviews).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %v", err)
+	}
+	return reviews, nil
+}
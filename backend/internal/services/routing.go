@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ride_sharing/backend/internal/config"
+	"ride_sharing/backend/internal/metrics"
+)
+
+// ErrNoRoute is returned when no route exists between two places.
+var ErrNoRoute = errors.New("no route found")
+
+// RouteEstimate is the driving distance and duration between two places.
+type RouteEstimate struct {
+	DistanceMeters int
+	Duration       time.Duration
+}
+
+// RoutingProvider estimates trips between two free-form addresses.
+type RoutingProvider interface {
+	Route(ctx context.Context, from, to string) (*RouteEstimate, error)
+}
+
+// NewRoutingProvider builds the provider selected by cfg.RoutingProvider.
+// Google is backed by the straight-line estimate so a failed lookup still
+// yields a figure.
+func NewRoutingProvider(cfg *config.Config, places PlacesProvider) (RoutingProvider, error) {
+	haversine := NewHaversineRouter(places, cfg.RoutingAverageSpeedKmh)
+	switch cfg.RoutingProvider {
+	case "google":
+		return &FallbackRouter{Primary: NewGoogleDistanceMatrixRouter(cfg), Fallback: haversine}, nil
+	case "haversine":
+		return haversine, nil
+	default:
+		return nil, fmt.Errorf("unknown routing provider %q", cfg.RoutingProvider)
+	}
+}
+
+// GoogleDistanceMatrixRouter implements RoutingProvider with the Google
+// Distance Matrix API.
+type GoogleDistanceMatrixRouter struct {
+	APIKey string
+	client *http.Client
+}
+
+func NewGoogleDistanceMatrixRouter(cfg *config.Config) *GoogleDistanceMatrixRouter {
+	return &GoogleDistanceMatrixRouter{
+		APIKey: cfg.GoogleMapsAPIKey,
+		client: newGoogleMapsClient(cfg.GoogleMapsAPIKey, cfg.PlacesTimeout),
+	}
+}
+
+func (g *GoogleDistanceMatrixRouter) Route(ctx context.Context, from, to string) (*RouteEstimate, error) {
+	params := url.Values{}
+	params.Set("origins", from)
+	params.Set("destinations", to)
+	params.Set("mode", "driving")
+	params.Set("units", "metric")
+
+	metrics.PlacesAPICalls.WithLabelValues("distance_matrix").Inc()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleMapsBaseURL+"/distancematrix/json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		metrics.PlacesAPIFailures.WithLabelValues("distance_matrix").Inc()
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		metrics.PlacesAPIFailures.WithLabelValues("distance_matrix").Inc()
+		return nil, fmt.Errorf("distance matrix API responded with HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Status string `json:"status"`
+		Rows   []struct {
+			Elements []struct {
+				Status   string `json:"status"`
+				Distance struct {
+					Value int `json:"value"`
+				} `json:"distance"`
+				Duration struct {
+					Value int `json:"value"`
+				} `json:"duration"`
+			} `json:"elements"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		metrics.PlacesAPIFailures.WithLabelValues("distance_matrix").Inc()
+		return nil, fmt.Errorf("failed to decode distance matrix response: %v", err)
+	}
+	if err := checkGoogleStatus("distance_matrix", body.Status); err != nil {
+		return nil, err
+	}
+	if len(body.Rows) == 0 || len(body.Rows[0].Elements) == 0 || body.Rows[0].Elements[0].Status != "OK" {
+		return nil, ErrNoRoute
+	}
+
+	element := body.Rows[0].Elements[0]
+	return &RouteEstimate{
+		DistanceMeters: element.Distance.Value,
+		Duration:       time.Duration(element.Duration.Value) * time.Second,
+	}, nil
+}
+
+// Roads are rarely straight; scale the great-circle distance to
+// approximate the driven one.
+const haversineDetourFactor = 1.3
+
+// HaversineRouter estimates routes offline from the straight-line distance
+// between geocoded endpoints and an average speed.
+type HaversineRouter struct {
+	places   PlacesProvider
+	speedKmh float64
+}
+
+func NewHaversineRouter(places PlacesProvider, speedKmh float64) *HaversineRouter {
+	return &HaversineRouter{places: places, speedKmh: speedKmh}
+}
+
+func (h *HaversineRouter) Route(ctx context.Context, from, to string) (*RouteEstimate, error) {
+	origin, err := h.locate(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := h.locate(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	km := haversineKm(origin.Lat, origin.Lng, destination.Lat, destination.Lng) * haversineDetourFactor
+	return &RouteEstimate{
+		DistanceMeters: int(km * 1000),
+		Duration:       time.Duration(km / h.speedKmh * float64(time.Hour)).Round(time.Minute),
+	}, nil
+}
+
+func (h *HaversineRouter) locate(ctx context.Context, address string) (*Place, error) {
+	places, err := h.places.Geocode(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(places) == 0 {
+		return nil, ErrNoRoute
+	}
+	return &places[0], nil
+}
+
+// FallbackRouter asks Primary and, if it fails, Fallback.
+type FallbackRouter struct {
+	Primary  RoutingProvider
+	Fallback RoutingProvider
+}
+
+func (f *FallbackRouter) Route(ctx context.Context, from, to string) (*RouteEstimate, error) {
+	estimate, err := f.Primary.Route(ctx, from, to)
+	if err == nil {
+		return estimate, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	slog.WarnContext(ctx, "primary routing failed, using fallback", "error", err)
+	return f.Fallback.Route(ctx, from, to)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SavedSearchService alerts passengers when a ride matches one of their
+// saved searches.
+type SavedSearchService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewSavedSearchService(db *gorm.DB, notifications *NotificationService) *SavedSearchService {
+	return &SavedSearchService{db: db, notifications: notifications}
+}
+
+// MatchRide notifies the owners of the saved searches a ride matches. Each
+// search is alerted about a ride at most once, however often the ride is
+// edited, and rides that cannot be booked match nothing. It returns the
+// number of passengers notified.
+func (s *SavedSearchService) MatchRide(ctx context.Context, rideID string) (int, error) {
+	notified := map[string]bool{}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ride models.Ride
+		if err := tx.First(&ride, "id = ?", rideID).Error; err != nil {
+			return fmt.Errorf("failed to get ride: %v", err)
+		}
+		if ride.Status != models.RideAvailable {
+			return nil
+		}
+
+		var searches []models.SavedSearch
+		if err := tx.Where("\"from\" COLLATE \"C\" = ? AND \"to\" COLLATE \"C\" = ?", ride.From, ride.To).
+			Where("date_from <= ? AND date_to >= ?", ride.DepartureDate(), ride.DepartureDate()).
+			Where("seats <= ?", ride.Seats).
+			Where("max_price IS NULL OR max_price >= ?", ride.Price).
+			Where("user_id <> ?", ride.Driver).
+			Find(&searches).Error; err != nil {
+			return fmt.Errorf("failed to find saved searches: %v", err)
+		}
+
+		for _, search := range searches {
+			if !search.Filters.Matches(ride.Preferences, ride.BookingMode) {
+				continue
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.SavedSearchAlert{SavedSearchID: search.ID, RideID: ride.ID})
+			if result.Error != nil {
+				return fmt.Errorf("failed to record saved search alert: %v", result.Error)
+			}
+			if result.RowsAffected == 0 || notified[search.UserID] {
+				continue
+			}
+
+			if err := s.notifications.Notify(tx, &models.Notification{
+				UserID: search.UserID,
+				Type:   models.NotificationRideMatch,
+				Message: fmt.Sprintf("A ride from %s to %s on %s at %s matches your saved search.",
+					ride.From, ride.To, ride.DepartureDate(), ride.DepartureClock()),
+				RideID: ride.ID,
+			}); err != nil {
+				return err
+			}
+			notified[search.UserID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(notified), nil
+}
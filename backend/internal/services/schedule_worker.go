@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ScheduleWorker keeps rides from recurring schedules created a fixed
+// number of days ahead.
+type ScheduleWorker struct {
+	schedules *ScheduleService
+	interval  time.Duration
+}
+
+func NewScheduleWorker(schedules *ScheduleService, interval time.Duration) *ScheduleWorker {
+	return &ScheduleWorker{schedules: schedules, interval: interval}
+}
+
+// Run creates due rides at start and then every interval until ctx is
+// cancelled.
+func (w *ScheduleWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.schedules.MaterializeAll(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to create scheduled rides", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
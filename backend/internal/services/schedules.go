@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ride_sharing/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleService creates rides from recurring schedules. Each ride records
+// the schedule and date it was created for, and a unique index on the pair
+// makes creating an occurrence twice a no-op.
+type ScheduleService struct {
+	db          *gorm.DB
+	searches    *SavedSearchService
+	horizonDays int
+}
+
+func NewScheduleService(db *gorm.DB, searches *SavedSearchService, horizonDays int) *ScheduleService {
+	return &ScheduleService{db: db, searches: searches, horizonDays: horizonDays}
+}
+
+// Materialize creates the schedule's rides departing after now and at most
+// horizonDays ahead. Dates that already had a ride, even one since moved or
+// cancelled, are skipped. It returns the IDs of the rides created, which
+// the caller should match against saved searches once committed.
+func (s *ScheduleService) Materialize(tx *gorm.DB, schedule *models.RideSchedule, now time.Time) ([]string, error) {
+	if schedule.Status != models.ScheduleActive {
+		return nil, nil
+	}
+
+	var created []string
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	for i := 0; i <= s.horizonDays; i++ {
+		day := today.AddDate(0, 0, i)
+		if !schedule.OccursOn(day) {
+			continue
+		}
+		ride := schedule.RideOn(day.Format("2006-01-02"))
+		departure, err := ride.Departure()
+		if err != nil {
+			return created, fmt.Errorf("failed to parse departure: %v", err)
+		}
+		if !departure.After(now) {
+			continue
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ride)
+		if result.Error != nil {
+			return created, fmt.Errorf("failed to create ride: %v", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			created = append(created, ride.ID)
+		}
+	}
+	return created, nil
+}
+
+// MaterializeAll tops up the rides of every active schedule and alerts
+// saved searches the new rides match. A schedule that fails is logged and
+// skipped.
+func (s *ScheduleService) MaterializeAll(ctx context.Context) error {
+	db := s.db.WithContext(ctx)
+
+	var schedules []models.RideSchedule
+	if err := db.Where("status = ?", models.ScheduleActive).Find(&schedules).Error; err != nil {
+		return fmt.Errorf("failed to find schedules: %v", err)
+	}
+
+	now := time.Now()
+	for i := range schedules {
+		schedule := &schedules[i]
+		var created []string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			created, err = s.Materialize(tx, schedule, now)
+			return err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create scheduled rides", "schedule_id", schedule.ID, "error", err)
+			continue
+		}
+		if len(created) > 0 {
+			slog.InfoContext(ctx, "created scheduled rides", "schedule_id", schedule.ID, "rides", len(created))
+		}
+		s.AlertSavedSearches(ctx, created)
+	}
+	return nil
+}
+
+// AlertSavedSearches matches committed rides of a schedule against saved
+// searches. Failures are logged; the rides stand.
+func (s *ScheduleService) AlertSavedSearches(ctx context.Context, rideIDs []string) {
+	for _, id := range rideIDs {
+		notified, err := s.searches.MatchRide(ctx, id)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to match saved searches", "ride_id", id, "error", err)
+			continue
+		}
+		if notified > 0 {
+			slog.InfoContext(ctx, "alerted saved searches", "ride_id", id, "users", notified)
+		}
+	}
+}
+
+// UpcomingRides returns the schedule's open rides departing after now,
+// locked for update.
+func (s *ScheduleService) UpcomingRides(tx *gorm.DB, scheduleID string, now time.Time) ([]models.Ride, error) {
+	var rides []models.Ride
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("schedule_id = ? AND status IN ? AND date >= ?", scheduleID,
+			[]string{models.RideAvailable, models.RideFull}, now.Format("2006-01-02")).
+		Order("date, time").Find(&rides).Error; err != nil {
+		return nil, fmt.Errorf("failed to get scheduled rides: %v", err)
+	}
+
+	upcoming := rides[:0]
+	for _, ride := range rides {
+		if departure, err := ride.Departure(); err == nil && departure.After(now) {
+			upcoming = append(upcoming, ride)
+		}
+	}
+	return upcoming, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"ride_sharing/backend/internal/config"
+)
+
+// ErrObjectNotFound is returned by Storage implementations for missing keys.
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage keeps uploaded files. Keys are slash-separated paths such as
+// "users/<id>/<photo>.jpg".
+type Storage interface {
+	// Put stores size bytes from body under key, replacing any existing
+	// object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public address the object is served from.
+	URL(key string) string
+}
+
+// NewStorage builds the storage selected by cfg.StorageProvider.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageProvider {
+	case "local":
+		return NewLocalStorage(cfg.StorageLocalDir, cfg.StoragePublicURL)
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PublicURL:       cfg.StoragePublicURL,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown storage provider %q", cfg.StorageProvider)
+	}
+}
+
+// validKey rejects keys that could escape the storage root.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
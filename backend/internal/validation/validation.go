@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// Date and clock layouts accepted by the "date" and "clock" tags.
+const (
+	DateLayout  = "2006-01-02"
+	ClockLayout = "15:04"
+)
+
+// Errors maps a JSON field name to a human readable message.
+type Errors map[string]string
+
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s %s", field, e[field]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Add records a message for field unless one is already present.
+func (e Errors) Add(field, message string) {
+	if _, exists := e[field]; !exists {
+		e[field] = message
+	}
+}
+
+// Validator is implemented by inputs with cross-field rules that struct
+// tags cannot express. It runs after the tag rules.
+type Validator interface {
+	Validate() Errors
+}
+
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names so clients can map errors to inputs
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// Compare decimal amounts with the numeric tags (gt, lte, ...)
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		f, _ := field.Interface().(decimal.Decimal).Float64()
+		return f
+	}, decimal.Decimal{})
+	v.RegisterValidation("money", func(fl validator.FieldLevel) bool {
+		cents := fl.Field().Float() * 100
+		return math.Abs(cents-math.Round(cents)) < 1e-6
+	})
+
+	v.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		_, err := ParseDate(fl.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("clock", func(fl validator.FieldLevel) bool {
+		_, err := ParseClock(fl.Field().String())
+		return err == nil
+	})
+
+	return v
+}
+
+// Struct validates v against its `validate` tags and, if v implements
+// Validator, its cross-field rules. It returns Errors or nil.
+func Struct(v interface{}) error {
+	errs := Errors{}
+
+	if err := validate.Struct(v); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return err
+		}
+		for _, fe := range fieldErrs {
+			errs.Add(fieldName(fe), message(fe))
+		}
+	}
+
+	if sv, ok := v.(Validator); ok {
+		for field, msg := range sv.Validate() {
+			errs.Add(field, msg)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// WriteError responds with 400 and a per-field JSON body for Errors, or a
+// plain 400 for anything else.
+func WriteError(w http.ResponseWriter, err error) {
+	var errs Errors
+	if !errors.As(err, &errs) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  "Validation failed",
+		"fields": errs,
+	})
+}
+
+// ParseDate parses a YYYY-MM-DD date.
+func ParseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+// ParseClock parses an HH:MM time, also accepting HH:MM:SS as returned by
+// Postgres time columns.
+func ParseClock(value string) (time.Time, error) {
+	if t, err := time.Parse(ClockLayout, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("15:04:05", value)
+}
+
+// NormalizeClock returns a time accepted by ParseClock as HH:MM, so equal
+// times compare equal. Unparseable values are returned unchanged.
+func NormalizeClock(value string) string {
+	t, err := ParseClock(value)
+	if err != nil {
+		return value
+	}
+	return t.Format(ClockLayout)
+}
+
+// fieldName returns the dotted JSON path of the failing field without the
+// top-level struct name.
+func fieldName(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "e164":
+		return "must be an international phone number such as +14155550123"
+	case "bcp47_language_tag":
+		return "must be a language tag such as en or pt-BR"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "date":
+		return "must be a date in YYYY-MM-DD format"
+	case "clock":
+		return "must be a time in HH:MM format"
+	case "money":
+		return "must have at most two decimal places"
+	case "unique":
+		return "must not contain duplicates"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "nefield":
+		return "must differ from " + lowerFirst(fe.Param())
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}